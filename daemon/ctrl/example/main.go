@@ -2,18 +2,25 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/One-com/gone/daemon"
 	"github.com/One-com/gone/daemon/ctrl"
+	"github.com/One-com/gone/daemon/ctrl/logcmd"
+	"github.com/One-com/gone/daemon/ctrl/metriccmd"
 	"github.com/One-com/gone/daemon/srv"
+	"github.com/One-com/gone/http/handlers/accesslog"
 	"github.com/One-com/gone/log"
 	"github.com/One-com/gone/log/syslog"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/sink/memory"
 	"github.com/One-com/gone/sd"
 	"github.com/One-com/gone/signals"
 	"io"
 	"os"
 	"syscall"
+	"time"
 )
 
 var accessLogControl = newAccessLogCommand(serverLogFunc)
@@ -57,6 +64,8 @@ func onSignalExitGraceful() {
 
 func onSignalReload() {
 	log.Println("Signal Reload")
+	reopenLogFile()
+	applyLogConfig()
 	sd.Notify(0, "RELOADING=1")
 	daemon.Reload()
 }
@@ -76,6 +85,28 @@ func onSignalDecLogLevel() {
 	log.ALERT(fmt.Sprintf("Log level: %d\n", log.Level()))
 }
 
+// Reopen the log file - if any. Used for external log rotation.
+var reopenLogFile = func() {}
+
+// (Re)configure logging from the log config file - if any.
+func applyLogConfig() {
+	if logConfig == "" {
+		return
+	}
+	var cfg log.Config
+	f, err := os.Open(logConfig)
+	if err == nil {
+		err = json.NewDecoder(f).Decode(&cfg)
+		f.Close()
+	}
+	if err == nil {
+		err = cfg.Apply()
+	}
+	if err != nil {
+		log.ERROR("Log config not applied", "file", logConfig, "err", err)
+	}
+}
+
 func serverLogFunc(level int, message string) {
 	log.Log(syslog.Priority(level), message)
 }
@@ -83,15 +114,27 @@ func serverLogFunc(level int, message string) {
 //---------------------------------------------------------
 
 var controlSocket string
+var logFile string
+var logConfig string
 
 func init() {
 
 	flag.StringVar(&controlSocket, "s", "", "Path to control socket")
+	flag.StringVar(&logFile, "logfile", "", "Log to file instead of stderr")
+	flag.StringVar(&logConfig, "logconfig", "", "JSON log configuration file (reapplied on reload)")
 
 	flag.Parse()
 
 	ctrl.RegisterCommand("accesslog", accessLogControl)
 	ctrl.RegisterCommand("proc", procControl)
+	logcmd.RegisterCommands()
+
+	metricSink := memory.New()
+	metric.SetDefaultSink(metricSink)
+	metric.SetDefaultOptions(metric.FlushInterval(10 * time.Second))
+	ctrl.RegisterCommand("metrics", &metriccmd.Command{Sink: metricSink, Client: metric.Default()})
+
+	log.RegisterContextKey("request_id", accesslog.RequestIDKey)
 
 	/* Setup signalling */
 
@@ -104,7 +147,19 @@ func init() {
 		syscall.SIGTTOU: onSignalDecLogLevel,
 	}
 
+	if logFile != "" {
+		w, err := log.NewRotatingFileWriter(logFile, log.RotateSize(100*1024*1024), log.RotateCompress(true))
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.SetOutput(w)
+		reopenLogFile = log.ReopenFunc(w)
+		handledSignals[syscall.SIGUSR1] = reopenLogFile
+		ctrl.RegisterCommand("reopen", &reopenCommand{})
+	}
+
 	log.SetLevel(syslog.LOG_DEBUG)
+	applyLogConfig()
 	daemon.SetLogger(serverLogFunc)
 
 	signals.RunSignalHandler(handledSignals)
@@ -162,3 +217,21 @@ func (p *procCommand) Invoke(ctx context.Context, w io.Writer, cmd string, args
 	}
 	return
 }
+
+// A command reopening the log file
+
+type reopenCommand struct{}
+
+func (r *reopenCommand) ShortUsage() (syntax, comment string) {
+	comment = "reopen the log file"
+	return
+}
+
+func (r *reopenCommand) Usage(cmd string, w io.Writer) {
+	fmt.Fprintln(w, cmd, "reopen the log file after it has been moved")
+}
+
+func (r *reopenCommand) Invoke(ctx context.Context, w io.Writer, cmd string, args []string) (async func(), persistent string, err error) {
+	reopenLogFile()
+	return
+}
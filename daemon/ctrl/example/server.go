@@ -17,12 +17,18 @@ import (
 	"github.com/One-com/gone/http/graceful"
 	"github.com/One-com/gone/http/handlers/accesslog"
 	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/httplog"
 	"github.com/One-com/gone/log/syslog"
+	"github.com/One-com/gone/metric"
 )
 
+var requestCounter = metric.RegisterCounter("requests")
+
 func myHandlerFunc(s *Server, cfg string, revision int) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		curval := s.GetValue()
+		requestCounter.Inc(1)
+		log.FromContext(r.Context()).DEBUG("Serving request", "state", curval)
 		io.WriteString(w, fmt.Sprintf("I'm here. state: \"%s\", cfg: %s, rev: %d, pid %d\n", curval, cfg, revision, os.Getpid()))
 	})
 }
@@ -76,7 +82,10 @@ func newHTTPServer(cfg string, rev int) (s srv.Server) {
 
 	ctrl.RegisterCommand("value", srvctrl)
 
-	accesslogHandler := accesslog.NewDynamicLogHandler(myHandlerFunc(s4, cfg, rev), nil)
+	// Give each request a scoped Logger and an ID, which is logged by context aware logging.
+	accesslogHandler := accesslog.NewDynamicLogHandler(myHandlerFunc(s4, cfg, rev), nil,
+		accesslog.RequestID("X-Request-Id"),
+		accesslog.RequestContext(httplog.RequestContext(log.GetLogger("http"))))
 
 	accessLogControl.RegisterLogHandler("main", accesslogHandler)
 
@@ -0,0 +1,243 @@
+/*
+Package logcmd provides daemon/ctrl Commands for inspecting and changing the
+gone/log Logger hierarchy at runtime:
+
+	loggers                              List Loggers and their levels
+	loglevel [-logger name] [level|+|-]  Get, set, increase or decrease a Logger level
+	loglevel -spec "info,http=debug"     Apply a level spec to the Logger tree (see log.SetLevels)
+	logtail [-logger name] [level]       Output events reaching the Logger's Handler
+
+The root (default) Logger is used when no Logger name is given.
+logtail is persistent and survives daemon reloads like other ctrl commands.
+
+Register them all with the default names with:
+
+	logcmd.RegisterCommands()
+*/
+package logcmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/One-com/gone/daemon/ctrl"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"io"
+	"sort"
+	"strings"
+)
+
+// RegisterCommands registers the log commands as "loggers", "loglevel" and "logtail"
+func RegisterCommands() {
+	ctrl.RegisterCommand("loggers", &LoggersCommand{})
+	ctrl.RegisterCommand("loglevel", &LevelCommand{})
+	ctrl.RegisterCommand("logtail", &TailCommand{})
+}
+
+func loggerFlags(cmd string, w io.Writer) (fs *flag.FlagSet, name *string) {
+	fs = flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(w)
+	name = fs.String("logger", "", "Name of Logger (default is the root Logger)")
+	return
+}
+
+//---
+
+// LoggersCommand lists all named Loggers and their level
+type LoggersCommand struct{}
+
+// ShortUsage implements ctrl.Command
+func (c *LoggersCommand) ShortUsage() (syntax, comment string) {
+	comment = "List Loggers and their levels"
+	return
+}
+
+// Usage implements ctrl.Command
+func (c *LoggersCommand) Usage(cmd string, w io.Writer) {
+	fmt.Fprintln(w, cmd, "- list Loggers and their levels. The root Logger is listed as \"\"")
+}
+
+// Invoke implements ctrl.Command
+func (c *LoggersCommand) Invoke(ctx context.Context, w io.Writer, cmd string, args []string) (async func(), persistent string, err error) {
+	levels := log.Levels()
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%-8s %q\n", log.LevelName(levels[name]), name)
+	}
+	return
+}
+
+//---
+
+// LevelCommand gets or sets the level of a Logger
+type LevelCommand struct{}
+
+// ShortUsage implements ctrl.Command
+func (c *LevelCommand) ShortUsage() (syntax, comment string) {
+	syntax = "[-logger <name>] [<level>|+|-] | -spec <spec>"
+	comment = "Get or set log levels"
+	return
+}
+
+// Usage implements ctrl.Command
+func (c *LevelCommand) Usage(cmd string, w io.Writer) {
+	fmt.Fprintln(w, cmd, "[-logger <name>]            Show the level of the Logger")
+	fmt.Fprintln(w, cmd, "[-logger <name>] <level>    Set the level of the Logger")
+	fmt.Fprintln(w, cmd, "[-logger <name>] +|-        Increase/decrease the level of the Logger")
+	fmt.Fprintln(w, cmd, "-spec <spec>                Set levels of the Logger tree, like: info,http=debug")
+}
+
+// Invoke implements ctrl.Command
+func (c *LevelCommand) Invoke(ctx context.Context, w io.Writer, cmd string, args []string) (async func(), persistent string, err error) {
+	fs, name := loggerFlags(cmd, w)
+	spec := fs.String("spec", "", "Level spec for the Logger tree")
+	err = fs.Parse(args)
+	if err != nil {
+		fmt.Fprintf(w, "Syntax error: %s", err.Error())
+		return
+	}
+
+	if *spec != "" {
+		if perr := log.SetLevels(*spec); perr != nil {
+			fmt.Fprintln(w, perr)
+		}
+		return
+	}
+
+	l, ok := log.LookupLogger(*name)
+	if !ok {
+		fmt.Fprintln(w, "No such Logger")
+		return
+	}
+
+	if fs.NArg() > 0 {
+		var set bool
+		switch arg := fs.Arg(0); arg {
+		case "+":
+			set = l.IncLevel()
+		case "-":
+			set = l.DecLevel()
+		default:
+			lvl, perr := log.ParseLevel(arg)
+			if perr != nil {
+				fmt.Fprintln(w, perr)
+				return
+			}
+			set = l.SetLevel(lvl)
+		}
+		if !set {
+			fmt.Fprintln(w, "Setting the level failed. Level is:")
+		}
+	}
+	fmt.Fprintln(w, log.LevelName(l.Level()))
+	return
+}
+
+//---
+
+// TailCommand outputs events logged by a Logger to the control socket
+// until the connection is closed.
+// The events are tapped at the Handler of the Logger given, so if the
+// Logger has no Handler of its own, events from all Loggers passing events to
+// its parent Handler are output.
+// Events are queued and dropped if the socket can't keep up.
+type TailCommand struct{}
+
+// ShortUsage implements ctrl.Command
+func (c *TailCommand) ShortUsage() (syntax, comment string) {
+	syntax = "[-logger <name>] [<level>]"
+	comment = "Output log events"
+	return
+}
+
+// Usage implements ctrl.Command
+func (c *TailCommand) Usage(cmd string, w io.Writer) {
+	fmt.Fprintln(w, cmd, "[-logger <name>] [<level>]   Output events logged by the Logger at or below level")
+	fmt.Fprintln(w, "The Logger must itself log at that level for events to be generated.")
+}
+
+// Invoke implements ctrl.Command
+func (c *TailCommand) Invoke(ctx context.Context, w io.Writer, cmd string, args []string) (async func(), persistent string, err error) {
+	fs, name := loggerFlags(cmd, w)
+	err = fs.Parse(args)
+	if err != nil {
+		fmt.Fprintf(w, "Syntax error: %s", err.Error())
+		return
+	}
+
+	l, ok := log.LookupLogger(*name)
+	if !ok {
+		fmt.Fprintln(w, "No such Logger")
+		return
+	}
+
+	lvl := syslog.LOG_DEBUG
+	if fs.NArg() > 0 {
+		var perr error
+		lvl, perr = log.ParseLevel(fs.Arg(0))
+		if perr != nil {
+			fmt.Fprintln(w, perr)
+			return
+		}
+	}
+
+	persistent = strings.Join(append([]string{cmd}, args...), " ")
+
+	async = func() {
+		out := log.NewAsyncHandler(
+			log.LvlFilterHandler(lvl, log.NewStdFormatter(w, "", log.LstdFlags|log.Llevel|log.Lname)),
+			1000, log.Overflow(log.OverflowDropNewest))
+		addTap(l, out)
+		<-ctx.Done()
+		removeTap(l, out)
+		out.Close()
+	}
+	return
+}
+
+//---
+
+// RecorderCommand outputs the history kept by a log.FlightRecorder.
+// It's not registered by RegisterCommands(), since it needs the recorder:
+//
+//	ctrl.RegisterCommand("logdump", &logcmd.RecorderCommand{Recorder: fr})
+type RecorderCommand struct {
+	Recorder *log.FlightRecorder
+}
+
+// ShortUsage implements ctrl.Command
+func (c *RecorderCommand) ShortUsage() (syntax, comment string) {
+	syntax = "[-flush]"
+	comment = "Output recorded log events"
+	return
+}
+
+// Usage implements ctrl.Command
+func (c *RecorderCommand) Usage(cmd string, w io.Writer) {
+	fmt.Fprintln(w, cmd, "          Output the recorded log events")
+	fmt.Fprintln(w, cmd, "-flush    Pass the recorded log events on to the log and forget them")
+}
+
+// Invoke implements ctrl.Command
+func (c *RecorderCommand) Invoke(ctx context.Context, w io.Writer, cmd string, args []string) (async func(), persistent string, err error) {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(w)
+	flush := fs.Bool("flush", false, "Pass the events on and empty the recorder")
+	err = fs.Parse(args)
+	if err != nil {
+		fmt.Fprintf(w, "Syntax error: %s", err.Error())
+		return
+	}
+
+	if *flush {
+		c.Recorder.Dump()
+		return
+	}
+	c.Recorder.DumpTo(log.NewStdFormatter(w, "", log.LstdFlags|log.Llevel|log.Lname))
+	return
+}
@@ -0,0 +1,136 @@
+package logcmd_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/One-com/gone/daemon/ctrl"
+	"github.com/One-com/gone/daemon/ctrl/logcmd"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func invoke(t *testing.T, cmd ctrl.Command, args ...string) string {
+	var b bytes.Buffer
+	if _, _, err := cmd.Invoke(context.Background(), &b, "cmd", args); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestLevelCommand(t *testing.T) {
+	l := log.GetLogger("logcmd/level")
+	l.SetLevel(syslog.LOG_INFO)
+	defer log.SetLevelMap(nil)
+	cmd := &logcmd.LevelCommand{}
+
+	if out := invoke(t, cmd, "-logger", "logcmd/level"); out != "info\n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+	if out := invoke(t, cmd, "-logger", "logcmd/level", "warn"); out != "warning\n" || l.Level() != syslog.LOG_WARN {
+		t.Errorf("Level not set: %q", out)
+	}
+	if out := invoke(t, cmd, "-logger", "logcmd/level", "+"); out != "notice\n" {
+		t.Errorf("Level not increased: %q", out)
+	}
+	if out := invoke(t, cmd, "-logger", "logcmd/level", "-"); out != "warning\n" {
+		t.Errorf("Level not decreased: %q", out)
+	}
+	if out := invoke(t, cmd, "-logger", "logcmd/level", "nosuch"); !strings.HasPrefix(out, "Unknown log level") {
+		t.Errorf("Unexpected output %q", out)
+	}
+	if out := invoke(t, cmd, "-logger", "logcmd/nosuch"); out != "No such Logger\n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+	if out := invoke(t, cmd, "-spec", "logcmd/level=debug"); out != "" || l.Level() != syslog.LOG_DEBUG {
+		t.Errorf("Level spec not applied: %q", out)
+	}
+}
+
+func TestLoggersCommand(t *testing.T) {
+	log.GetLogger("logcmd/list").SetLevel(syslog.LOG_ERR)
+
+	out := invoke(t, &logcmd.LoggersCommand{})
+	if !strings.Contains(out, "error    \"logcmd/list\"\n") || !strings.Contains(out, " \"\"\n") {
+		t.Errorf("Unexpected output %q", out)
+	}
+}
+
+// A buffer written by the tail go-routine
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestTailCommand(t *testing.T) {
+	var orig bytes.Buffer
+	l := log.GetLogger("logcmd/tail")
+	l.SetLevel(syslog.LOG_DEBUG)
+	h := log.NewMinFormatter(&orig)
+	l.SetHandler(h)
+	defer l.SetHandler(nil)
+
+	var out syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	async, persistent, err := (&logcmd.TailCommand{}).Invoke(ctx, &out, "logtail", []string{"-logger", "logcmd/tail", "info"})
+	if err != nil || async == nil {
+		t.Fatal("Tail not started", err)
+	}
+	if persistent != "logtail -logger logcmd/tail info" {
+		t.Errorf("Unexpected persistent command %q", persistent)
+	}
+	done := make(chan struct{})
+	go func() {
+		async()
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for l.Handler() == log.Handler(h) {
+		if time.Now().After(deadline) {
+			t.Fatal("Tap not installed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	l.DEBUG("hidden")
+	l.INFO("tailed")
+	// Cloning the tap keeps tailing
+	l.SetPrefix("")
+	l.WARN("cloned")
+
+	for !strings.Contains(out.String(), "cloned") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Events not tailed: %q", out.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if s := out.String(); strings.Contains(s, "hidden") || !strings.Contains(s, "tailed") {
+		t.Errorf("Unexpected tail output %q", s)
+	}
+	// The original Handler (clone) is back
+	l.INFO("untapped")
+	if s := out.String(); strings.Contains(s, "untapped") {
+		t.Errorf("Tap not removed: %q", s)
+	}
+	if orig.String() != "<7>hidden\n<6>tailed\n<4>cloned\n<6>untapped\n" {
+		t.Errorf("Unexpected log output %q", orig.String())
+	}
+}
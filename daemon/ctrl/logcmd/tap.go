@@ -0,0 +1,160 @@
+package logcmd
+
+import (
+	"github.com/One-com/gone/log"
+	"io"
+	"sync"
+)
+
+// A tap is swapped in as the Handler of a Logger while it's being tailed,
+// passing events to the tails and then the original Handler.
+// To not break stdlib-like manipulation of the Logger Handler while tailing
+// (SetOutput(), SetFlags() etc.) the tap is cloneable, applying options to a clone of the
+// original Handler and wrapping the clone in a new tap sharing the tails.
+type tap struct {
+	orig log.Handler
+	*tails
+}
+
+type tails struct {
+	mu   sync.RWMutex
+	outs []log.Handler
+}
+
+// The tails of the tailed Loggers
+var (
+	tapmu sync.Mutex
+	taps  = make(map[*log.Logger]*tails)
+)
+
+func (t *tap) Log(e log.Event) error {
+	t.mu.RLock()
+	for _, out := range t.outs {
+		out.Log(e)
+	}
+	t.mu.RUnlock()
+	if t.orig == nil {
+		// Let the Logger pass the event to its parent
+		return log.ErrNotLogged
+	}
+	return t.orig.Log(e)
+}
+
+// Clone implements log.CloneableHandler
+func (t *tap) Clone(options ...log.HandlerOption) log.CloneableHandler {
+	clo, ok := t.orig.(log.CloneableHandler)
+	if !ok {
+		return t
+	}
+	return &tap{orig: clo.Clone(options...), tails: t.tails}
+}
+
+func noop(log.CloneableHandler) {}
+
+// The optional methods of formatters supporting stdlib-like manipulation
+type hasFlagsOption interface {
+	SetFlags(flags int) log.HandlerOption
+}
+
+type hasPrefixOption interface {
+	SetPrefix(prefix string) log.HandlerOption
+}
+
+type hasOutputOption interface {
+	SetOutput(w io.Writer) log.HandlerOption
+}
+
+type hasAutoColoringOption interface {
+	AutoColoring() log.HandlerOption
+}
+
+// Flags implements log.StdFormatter
+func (t *tap) Flags() (flags int) {
+	if f, ok := t.orig.(log.StdFormatter); ok {
+		flags = f.Flags()
+	}
+	return
+}
+
+// Prefix implements log.StdFormatter
+func (t *tap) Prefix() (prefix string) {
+	if f, ok := t.orig.(log.StdFormatter); ok {
+		prefix = f.Prefix()
+	}
+	return
+}
+
+// SetFlags returns a HandlerOption setting flags on the original Handler
+func (t *tap) SetFlags(flags int) log.HandlerOption {
+	if h, ok := t.orig.(hasFlagsOption); ok {
+		return h.SetFlags(flags)
+	}
+	return noop
+}
+
+// SetPrefix returns a HandlerOption setting prefix on the original Handler
+func (t *tap) SetPrefix(prefix string) log.HandlerOption {
+	if h, ok := t.orig.(hasPrefixOption); ok {
+		return h.SetPrefix(prefix)
+	}
+	return noop
+}
+
+// SetOutput returns a HandlerOption setting output of the original Handler
+func (t *tap) SetOutput(w io.Writer) log.HandlerOption {
+	if h, ok := t.orig.(hasOutputOption); ok {
+		return h.SetOutput(w)
+	}
+	return noop
+}
+
+// AutoColoring returns a HandlerOption making the original Handler do coloring
+func (t *tap) AutoColoring() log.HandlerOption {
+	if h, ok := t.orig.(hasAutoColoringOption); ok {
+		return h.AutoColoring()
+	}
+	return noop
+}
+
+func addTap(l *log.Logger, out log.Handler) {
+	tapmu.Lock()
+	defer tapmu.Unlock()
+
+	t, ok := taps[l]
+	if !ok {
+		t = &tails{}
+		taps[l] = t
+	}
+	t.mu.Lock()
+	t.outs = append(t.outs, out)
+	t.mu.Unlock()
+	if !ok {
+		l.SetHandler(&tap{orig: l.Handler(), tails: t})
+	}
+}
+
+func removeTap(l *log.Logger, out log.Handler) {
+	tapmu.Lock()
+	defer tapmu.Unlock()
+
+	t, ok := taps[l]
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	for i, o := range t.outs {
+		if o == out {
+			t.outs = append(t.outs[:i], t.outs[i+1:]...)
+			break
+		}
+	}
+	last := len(t.outs) == 0
+	t.mu.Unlock()
+	if last {
+		delete(taps, l)
+		// The tap might be a clone. Unless somebody else replaced the Handler meanwhile
+		if cur, ok := l.Handler().(*tap); ok && cur.tails == t {
+			l.SetHandler(cur.orig)
+		}
+	}
+}
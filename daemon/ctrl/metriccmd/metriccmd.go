@@ -0,0 +1,68 @@
+/*
+Package metriccmd provides a daemon/ctrl Command outputting the current values of
+gone/metric metrics kept by a memory Sink:
+
+	metrics [-flush] [prefix]    List metrics with names starting with prefix
+
+Register it with the Sink (and optionally the metric Client to flush) with:
+
+	sink := memory.New()
+	client := metric.NewClient(sink, metric.FlushInterval(10*time.Second))
+	ctrl.RegisterCommand("metrics", &metriccmd.Command{Sink: sink, Client: client})
+*/
+package metriccmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/sink/memory"
+	"io"
+)
+
+// Command outputs the Readings of a memory Sink, one per line.
+type Command struct {
+	Sink *memory.Sink
+	// If set, the Client can be flushed before output to get the current values of its Meters
+	Client *metric.Client
+}
+
+// ShortUsage implements ctrl.Command
+func (c *Command) ShortUsage() (syntax, comment string) {
+	syntax = "[-flush] [<prefix>]"
+	comment = "List metrics"
+	return
+}
+
+// Usage implements ctrl.Command
+func (c *Command) Usage(cmd string, w io.Writer) {
+	fmt.Fprintln(w, cmd, "[<prefix>]            List metrics with names starting with prefix")
+	fmt.Fprintln(w, cmd, "-flush [<prefix>]     Flush the metric Client first to get the current values")
+	fmt.Fprintln(w, "Each metric is listed as: name{tags} type value (recent stats of the last flush interval)")
+}
+
+// Invoke implements ctrl.Command
+func (c *Command) Invoke(ctx context.Context, w io.Writer, cmd string, args []string) (async func(), persistent string, err error) {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(w)
+	flush := fs.Bool("flush", false, "Flush the metric Client first")
+	err = fs.Parse(args)
+	if err != nil {
+		fmt.Fprintf(w, "Syntax error: %s", err.Error())
+		return
+	}
+
+	if *flush {
+		if c.Client == nil {
+			fmt.Fprintln(w, "No metric Client to flush")
+			return
+		}
+		c.Client.Flush()
+	}
+
+	for _, r := range c.Sink.Snapshot(fs.Arg(0)) {
+		fmt.Fprintln(w, r.String())
+	}
+	return
+}
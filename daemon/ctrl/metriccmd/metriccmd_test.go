@@ -0,0 +1,53 @@
+package metriccmd_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/One-com/gone/daemon/ctrl/metriccmd"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/sink/memory"
+	"testing"
+	"time"
+)
+
+func TestInvoke(t *testing.T) {
+	sink := memory.New()
+	client := metric.NewClient(sink, metric.FlushInterval(time.Hour))
+	cmd := &metriccmd.Command{Sink: sink, Client: client}
+
+	gauge := client.RegisterGauge("http.conns")
+	counter := client.RegisterCounter("http.requests")
+	client.RegisterGauge("other").Set(1)
+	gauge.Set(3)
+	counter.Inc(2)
+	client.Flush()
+
+	var b bytes.Buffer
+	if _, _, err := cmd.Invoke(context.Background(), &b, "metrics", []string{"http."}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "http.conns gauge 3\nhttp.requests counter 2 (recent count=1 sum=2 min=2 max=2 mean=2)\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+
+	// Not flushed yet
+	gauge.Set(5)
+	b.Reset()
+	cmd.Invoke(context.Background(), &b, "metrics", []string{"http.conns"})
+	if b.String() != "http.conns gauge 3\n" {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+
+	b.Reset()
+	cmd.Invoke(context.Background(), &b, "metrics", []string{"-flush", "http.conns"})
+	if b.String() != "http.conns gauge 5\n" {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+
+	b.Reset()
+	(&metriccmd.Command{Sink: sink}).Invoke(context.Background(), &b, "metrics", []string{"-flush"})
+	if b.String() != "No metric Client to flush\n" {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+}
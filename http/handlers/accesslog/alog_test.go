@@ -2,11 +2,13 @@ package accesslog
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -58,3 +60,37 @@ func serve(l net.Listener, elogbuf io.Writer) {
 
 	s.Serve(l)
 }
+
+func TestRequestContext(t *testing.T) {
+	type key struct{}
+
+	var gotID, gotValue interface{}
+	inner := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotID, _ = GetRequestID(req.Context())
+		gotValue = req.Context().Value(key{})
+	})
+
+	h := NewDynamicLogHandler(inner, nil,
+		RequestID("X-Request-Id"),
+		RequestContext(func(w http.ResponseWriter, req *http.Request) context.Context {
+			id, _ := GetRequestID(req.Context())
+			return context.WithValue(req.Context(), key{}, "scoped-"+id)
+		}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-Id", "abc")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if gotID != "abc" || gotValue != "scoped-abc" {
+		t.Errorf("Unexpected request context values: %v, %v", gotID, gotValue)
+	}
+	if rec.Header().Get("X-Request-Id") != "abc" {
+		t.Error("Request ID not returned in response")
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if id, _ := gotID.(string); len(id) != 16 {
+		t.Errorf("Expected generated request ID, got %q", id)
+	}
+}
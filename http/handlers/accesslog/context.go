@@ -0,0 +1,68 @@
+package accesslog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// ContextFunction is called by a DynamicLogHandler before serving a request
+// to provide a new context for the request - typically derived from the request context.
+type ContextFunction func(w http.ResponseWriter, req *http.Request) context.Context
+
+// Option configures a DynamicLogHandler
+type Option func(*logHandler)
+
+// RequestContext makes the handler serve requests with the context returned by cf.
+// Use it to provide request scoped values to the wrapped handler.
+// Several RequestContext options are applied in order.
+//
+// To give each request a scoped Logger (see github.com/One-com/gone/log/httplog)
+// logging the request ID:
+//
+//	log.RegisterContextKey("req", accesslog.RequestIDKey)
+//	h = accesslog.NewDynamicLogHandler(h, nil,
+//		accesslog.RequestID("X-Request-Id"),
+//		accesslog.RequestContext(httplog.RequestContext(log.GetLogger("http"))))
+//
+// Request handlers then log with log.FromContext(req.Context()).
+func RequestContext(cf ContextFunction) Option {
+	return func(h *logHandler) {
+		h.cfs = append(h.cfs, cf)
+	}
+}
+
+type requestIDKey struct{}
+
+// RequestIDKey is the context key under which the RequestID option stores the request ID.
+// The value is a string.
+var RequestIDKey = requestIDKey{}
+
+// RequestID makes the handler store an ID for each request in the request context under RequestIDKey.
+// If header is not "" the ID is taken from that request header if present and returned in the
+// same response header.
+// Otherwise a random ID is generated.
+func RequestID(header string) Option {
+	return RequestContext(func(w http.ResponseWriter, req *http.Request) context.Context {
+		var id string
+		if header != "" {
+			id = req.Header.Get(header)
+		}
+		if id == "" {
+			var b [8]byte
+			rand.Read(b[:])
+			id = hex.EncodeToString(b[:])
+		}
+		if header != "" {
+			w.Header().Set(header, id)
+		}
+		return context.WithValue(req.Context(), RequestIDKey, id)
+	})
+}
+
+// GetRequestID returns the request ID stored in ctx by the RequestID option - if any.
+func GetRequestID(ctx context.Context) (id string, ok bool) {
+	id, ok = ctx.Value(RequestIDKey).(string)
+	return
+}
@@ -45,15 +45,20 @@ type logHandler struct {
 	writers []io.Writer
 	out     unsafe.Pointer // pointer to io.Writer
 	af      AuditFunction
+	cfs     []ContextFunction // applied to the request context in order
 }
 
 // NewDynamicLogHandler wraps around a provided handler and returns a DynamicLogHandler
 // capable of turning accesslog on/off dynamically.
 // If provided an AuditFunction it will be called after ServeHTTP on wrapped handler
 // returns
-func NewDynamicLogHandler(h http.Handler, af AuditFunction) DynamicLogHandler {
+func NewDynamicLogHandler(h http.Handler, af AuditFunction, options ...Option) DynamicLogHandler {
 	p := &sync.Pool{New: func() interface{} { return new(buffer) }}
-	return &logHandler{handler: h, bufpool: p, af: af}
+	lh := &logHandler{handler: h, bufpool: p, af: af}
+	for _, option := range options {
+		option(lh)
+	}
+	return lh
 }
 
 func (h *logHandler) ToggleAccessLog(old, new io.Writer) {
@@ -111,6 +116,12 @@ func (h *logHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	t := time.Now()
 
+	if h.cfs != nil {
+		for _, cf := range h.cfs {
+			req = req.WithContext(cf(w, req))
+		}
+	}
+
 	if out != nil {
 
 		outw := *((*io.Writer)(out))
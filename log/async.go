@@ -0,0 +1,172 @@
+package log
+
+import (
+	"errors"
+	"github.com/One-com/gone/log/syslog"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy determines what an AsyncHandler does when its queue is full.
+type OverflowPolicy int
+
+// Overflow policies for the AsyncHandler
+const (
+	OverflowBlock      OverflowPolicy = iota // Wait for room in the queue
+	OverflowDropNewest                       // Discard the event being logged
+	OverflowDropOldest                       // Discard the oldest queued event to make room
+	OverflowDropBelow                        // Discard events less severe than the drop level, block for the rest
+)
+
+// ErrHandlerClosed is returned when logging to a closed AsyncHandler
+var ErrHandlerClosed = errors.New("Handler closed")
+
+// AsyncHandler queues events and passes them to another Handler in a background
+// go-routine, so logging doesn't wait for slow Handlers/Writers.
+// Since events are pooled, they are copied before being queued.
+// Errors returned by the downstream Handler are lost.
+// Call Close() to drain the queue on shutdown.
+type AsyncHandler struct {
+	h       Handler
+	policy  OverflowPolicy
+	droplvl syslog.Priority
+
+	queue   chan asyncItem
+	done    chan struct{}
+	dropped uint64
+
+	mu     sync.RWMutex // protects closing the queue
+	closed bool
+}
+
+// A queued event - or a flush marker
+type asyncItem struct {
+	e     *event
+	flush chan struct{}
+}
+
+// AsyncOption configures an AsyncHandler
+type AsyncOption func(*AsyncHandler)
+
+// Overflow sets the OverflowPolicy. The default is OverflowBlock.
+func Overflow(policy OverflowPolicy) AsyncOption {
+	return func(a *AsyncHandler) { a.policy = policy }
+}
+
+// DropLevel sets the OverflowDropBelow policy, discarding events with a level
+// above (less severe than) level when the queue is full.
+func DropLevel(level syslog.Priority) AsyncOption {
+	return func(a *AsyncHandler) {
+		a.policy = OverflowDropBelow
+		a.droplvl = level
+	}
+}
+
+// NewAsyncHandler starts a go-routine passing events to h through a queue of the given size.
+func NewAsyncHandler(h Handler, size int, options ...AsyncOption) *AsyncHandler {
+	a := &AsyncHandler{
+		h:       h,
+		droplvl: syslog.LOG_DEBUG,
+		queue:   make(chan asyncItem, size),
+		done:    make(chan struct{}),
+	}
+	for _, option := range options {
+		option(a)
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncHandler) run() {
+	defer close(a.done)
+	for item := range a.queue {
+		if item.flush != nil {
+			close(item.flush)
+			continue
+		}
+		a.h.Log(Event{item.e})
+	}
+}
+
+// Log implements the Handler interface by queueing a copy of the event.
+func (a *AsyncHandler) Log(e Event) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrHandlerClosed
+	}
+
+	// Don't copy events which are dropped anyway
+	drop := a.policy == OverflowDropNewest || (a.policy == OverflowDropBelow && e.Lvl > a.droplvl)
+	if drop && len(a.queue) == cap(a.queue) {
+		atomic.AddUint64(&a.dropped, 1)
+		return nil
+	}
+
+	item := asyncItem{e: e.clone()}
+
+	select {
+	case a.queue <- item:
+		return nil
+	default:
+	}
+
+	// Queue is full
+	if drop {
+		atomic.AddUint64(&a.dropped, 1)
+		return nil
+	}
+	if a.policy == OverflowDropOldest {
+		for {
+			select {
+			case a.queue <- item:
+				return nil
+			default:
+			}
+			select {
+			case old := <-a.queue:
+				if old.flush != nil {
+					// Everything queued before the marker has been handled or dropped.
+					close(old.flush)
+					continue
+				}
+				atomic.AddUint64(&a.dropped, 1)
+			default:
+			}
+		}
+	}
+	a.queue <- item
+	return nil
+}
+
+// Dropped returns the number of events discarded due to a full queue.
+func (a *AsyncHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Flush waits until all events queued before the call has been passed to the downstream Handler.
+// With OverflowDropOldest, Flush may return when the queue overflows while the downstream
+// Handler is still handling the last event queued before the call.
+func (a *AsyncHandler) Flush() {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	a.queue <- asyncItem{flush: flushed}
+	a.mu.RUnlock()
+	<-flushed
+}
+
+// Close stops accepting events and waits for the queue to be drained.
+func (a *AsyncHandler) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	<-a.done
+	return nil
+}
@@ -0,0 +1,162 @@
+package log_test
+
+import (
+	"bytes"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A Handler which blocks until released
+type gateHandler struct {
+	started chan string
+	gate    chan struct{}
+	msgs    []string
+}
+
+func (g *gateHandler) Log(e log.Event) error {
+	g.started <- e.Msg
+	<-g.gate
+	g.msgs = append(g.msgs, e.Msg)
+	return nil
+}
+
+func TestAsyncHandlerCopiesEvents(t *testing.T) {
+	var b bytes.Buffer
+	a := log.NewAsyncHandler(log.NewStdFormatter(&b, "", 0), 10)
+	l := log.NewLogger(syslog.LOG_DEBUG, a)
+
+	kv := []interface{}{"k", "v1"}
+	l.INFO("first", kv...)
+	kv[1] = "v2" // must not change the queued event
+	l.With("ctx", 1).INFO("second", kv...)
+	a.Flush()
+
+	if b.String() != "first k=v1\nsecond ctx=1 k=v2\n" {
+		t.Errorf("Unexpected output: %q", b.String())
+	}
+	a.Close()
+	if err := l.Log(syslog.LOG_INFO, "closed"); err == nil {
+		t.Error("Logging to closed handler succeeded")
+	}
+}
+
+func TestAsyncHandlerOverflow(t *testing.T) {
+
+	tests := []struct {
+		opt      log.AsyncOption
+		expected string
+	}{
+		{log.Overflow(log.OverflowDropNewest), "0,1,2"},
+		{log.Overflow(log.OverflowDropOldest), "0,3,4"},
+		{log.DropLevel(syslog.LOG_WARN), "0,1,2"},
+	}
+
+	for _, test := range tests {
+		g := &gateHandler{started: make(chan string, 10), gate: make(chan struct{})}
+		a := log.NewAsyncHandler(g, 2, test.opt)
+		l := log.NewLogger(syslog.LOG_DEBUG, a)
+
+		l.INFO("0")
+		<-g.started // "0" is being handled and the queue is empty
+		for _, msg := range []string{"1", "2", "3", "4"} {
+			l.INFO(msg)
+		}
+
+		close(g.gate)
+		a.Close()
+
+		result := strings.Join(g.msgs, ",")
+		if result != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, result)
+		}
+		if a.Dropped() != 2 {
+			t.Errorf("Expected 2 dropped events, got %d", a.Dropped())
+		}
+	}
+
+	// Events at or above the drop level wait for room in the queue
+	g := &gateHandler{started: make(chan string, 10), gate: make(chan struct{})}
+	a := log.NewAsyncHandler(g, 1, log.DropLevel(syslog.LOG_WARN))
+	l := log.NewLogger(syslog.LOG_DEBUG, a)
+
+	l.INFO("0")
+	<-g.started
+	l.INFO("1")
+	l.INFO("2")
+	done := make(chan struct{})
+	go func() {
+		l.ERROR("E")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Error("ERROR was not blocked by a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(g.gate)
+	<-done
+	a.Close()
+
+	if result := strings.Join(g.msgs, ","); result != "0,1,E" {
+		t.Errorf("Expected 0,1,E, got %s", result)
+	}
+}
+
+// Events dropped because the queue is full are not copied
+func TestAsyncHandlerDropNoAlloc(t *testing.T) {
+	for _, opt := range []log.AsyncOption{log.Overflow(log.OverflowDropNewest), log.DropLevel(syslog.LOG_WARN)} {
+		g := &gateHandler{started: make(chan string, 10), gate: make(chan struct{})}
+		a := log.NewAsyncHandler(g, 1, opt)
+		l := log.NewLogger(syslog.LOG_DEBUG, a)
+
+		l.INFO("0")
+		<-g.started
+		l.INFO("1") // fills the queue
+		if allocs := testing.AllocsPerRun(100, func() { l.INFO("dropped") }); allocs != 0 {
+			t.Errorf("Dropping an event allocated %v times", allocs)
+		}
+		close(g.gate)
+		a.Close()
+		if a.Dropped() != 101 {
+			t.Errorf("Expected 101 dropped events, got %d", a.Dropped())
+		}
+	}
+}
+
+// Drop-oldest completes a flush marker it drops instead of blocking
+func TestAsyncHandlerDropOldestFlush(t *testing.T) {
+	g := &gateHandler{started: make(chan string, 10), gate: make(chan struct{})}
+	a := log.NewAsyncHandler(g, 1, log.Overflow(log.OverflowDropOldest))
+	l := log.NewLogger(syslog.LOG_DEBUG, a)
+
+	l.INFO("0")
+	<-g.started
+	flushed := make(chan struct{})
+	go func() {
+		a.Flush()
+		close(flushed)
+	}()
+	time.Sleep(50 * time.Millisecond) // let the flush marker fill the queue
+
+	logged := make(chan struct{})
+	go func() {
+		l.INFO("1")
+		close(logged)
+	}()
+	for _, c := range []chan struct{}{logged, flushed} {
+		select {
+		case <-c:
+		case <-time.After(time.Second):
+			t.Fatal("Blocked by a full queue")
+		}
+	}
+	close(g.gate)
+	a.Close()
+
+	if result := strings.Join(g.msgs, ","); result != "0,1" {
+		t.Errorf("Expected 0,1, got %s", result)
+	}
+}
@@ -1,9 +1,11 @@
 package log
 
 import (
+	"github.com/One-com/gone/log/syslog"
 	"io/ioutil"
 	stdlog "log"
 	"testing"
+	"time"
 )
 
 // Performance of the standard library to compare
@@ -116,3 +118,35 @@ func BenchmarkParallelMinDEBUGok(b *testing.B) {
 		}
 	})
 }
+
+// Typed fields at a disabled level
+func BenchmarkFieldsDisabled(b *testing.B) {
+	h := NewJSONFormatter(ioutil.Discard)
+	l := NewLogger(syslog.LOG_INFO, h)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.LogFields(syslog.LOG_DEBUG, "test", String("s", "value"), Int("n", i), Dur("d", time.Second))
+	}
+}
+
+// Typed fields formatted as JSON
+func BenchmarkFieldsJSON(b *testing.B) {
+	h := NewJSONFormatter(ioutil.Discard)
+	l := NewLogger(syslog.LOG_INFO, h).With(String("service", "test"))
+	l.DoTime(true)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.LogFields(syslog.LOG_INFO, "test", String("s", "value"), Int("n", i), Dur("d", time.Second))
+	}
+}
+
+// The same with plain key/value pairs for comparison
+func BenchmarkKeyvalsJSON(b *testing.B) {
+	h := NewJSONFormatter(ioutil.Discard)
+	l := NewLogger(syslog.LOG_INFO, h).With("service", "test")
+	l.DoTime(true)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.INFO("test", "s", "value", "n", i, "d", time.Second)
+	}
+}
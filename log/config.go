@@ -0,0 +1,323 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Config is a declarative configuration of Handlers and the Loggers using them.
+// It can be decoded from JSON (like with jconf) or by hugorm.Unmarshal:
+//
+//	{
+//	  "handlers": {
+//	    "console": {"type": "std", "flags": ["level", "date", "time", "name"]},
+//	    "errors":  {"type": "json", "output": "/var/log/app/errors.log", "level": "error"},
+//	    "both":    {"type": "multi", "handlers": ["console", "errors"]},
+//	    "rsyslog": {"type": "syslog", "network": "tcp", "address": "logs.example.com:514"}
+//	  },
+//	  "loggers": {"": "both", "http/access": "console"},
+//	  "levels":  {"": "info", "http": "debug"}
+//	}
+type Config struct {
+	Handlers map[string]HandlerConfig // Handlers by name
+	Loggers  map[string]string        // The name of the Handler to use for named Loggers. "" is the root Logger
+	Levels   map[string]string        // Level rules as for SetLevelMap()
+}
+
+// HandlerConfig describes a Handler
+type HandlerConfig struct {
+	// The formatter: "std" (default), "min", "json", "logfmt", "template", "syslog" or "journal".
+	// Or "multi" sending events to all of Handlers.
+	Type string
+	// "stderr" (default), "stdout", "discard" or a file path to append to.
+	Output string
+	// For "syslog": Send to a syslog daemon instead of Output, using the network
+	// ("udp", "tcp", "unix" or "unixgram") and address. If only an address is given, "udp" is used.
+	// Network "local" sends to the local syslog daemon. See NewSyslogWriter.
+	Network string
+	Address string
+	// Formatter flags by name: "date", "time", "microseconds", "longfile", "shortfile", "utc",
+	// "level", "pid", "color", "name" and "std" (for date and time)
+	Flags []string
+	// Prefix for the "std" formatter
+	Prefix string
+	// Pattern for the "template" formatter
+	Template string
+	// Timestamp layout for the "json", "logfmt" and "template" formatters
+	TimeFormat string
+	// Only pass events at this level or more severe to the Handler
+	Level string
+	// Handler names for "multi"
+	Handlers []string
+}
+
+var flagNames = map[string]int{
+	"date":         Ldate,
+	"time":         Ltime,
+	"microseconds": Lmicroseconds,
+	"longfile":     Llongfile,
+	"shortfile":    Lshortfile,
+	"utc":          LUTC,
+	"level":        Llevel,
+	"pid":          Lpid,
+	"color":        Lcolor,
+	"name":         Lname,
+	"std":          LstdFlags,
+}
+
+// The state of the last applied Config
+var applied struct {
+	mu      sync.Mutex
+	loggers map[string]bool
+	files   map[string]io.WriteCloser // output files and syslog connections
+	retired map[string]io.WriteCloser // outputs no longer used, closed by the next Apply
+}
+
+// A Handler being built from a Config
+type configHandler struct {
+	h        Handler
+	codeinfo bool // needs file/line info
+}
+
+type configBuilder struct {
+	cfg      *Config
+	handlers map[string]*configHandler
+	building map[string]bool // to detect loops
+	files    map[string]io.WriteCloser
+	opened   []io.WriteCloser // outputs opened by this builder
+}
+
+// Apply builds the Handlers of the Config and atomically swaps them into the Loggers
+// and sets the levels. Events logged while applying the Config are logged by either the
+// old or the new Handlers.
+// Loggers given a Handler by a previously applied Config, but not by this Config, get their
+// Handler removed - passing events to their parent Logger. (Except the root Logger).
+// Output files (and syslog connections) are kept open when applying a new Config using the same files.
+// Files no longer used are closed by the following Apply, so events still being logged by the old
+// Handlers are not lost.
+// Loggers do code info if one of their Handlers has the "shortfile" or "longfile" flag, and stop doing it
+// if not.
+// If Levels is nil, the level rules are left unchanged. An empty Levels map removes the rules.
+// Apply can thus be called on every daemon reload.
+// If the Config is invalid, an error is returned and nothing is changed.
+func (c *Config) Apply() error {
+	applied.mu.Lock()
+	defer applied.mu.Unlock()
+
+	b := &configBuilder{
+		cfg:      c,
+		handlers: make(map[string]*configHandler),
+		building: make(map[string]bool),
+		files:    make(map[string]io.WriteCloser),
+	}
+	fail := func(err error) error {
+		for _, w := range b.opened {
+			w.Close()
+		}
+		return err
+	}
+
+	for name := range c.Handlers {
+		if _, err := b.build(name); err != nil {
+			return fail(err)
+		}
+	}
+	loggers := make(map[string]*configHandler, len(c.Loggers))
+	for lname, hname := range c.Loggers {
+		h, ok := b.handlers[hname]
+		if !ok {
+			return fail(fmt.Errorf("Unknown handler %q for logger %q", hname, lname))
+		}
+		loggers[lname] = h
+	}
+	for _, level := range c.Levels {
+		if _, err := ParseLevel(level); err != nil {
+			return fail(err)
+		}
+	}
+
+	// Everything is valid. Swap in the new Handlers
+	for lname, h := range loggers {
+		l := GetLogger(lname)
+		doCodeInfo(l, h.codeinfo)
+		l.SetHandler(h.h)
+	}
+	for lname := range applied.loggers {
+		if _, ok := loggers[lname]; !ok {
+			l := GetLogger(lname)
+			doCodeInfo(l, false)
+			if lname != "" {
+				l.SetHandler(nil)
+			}
+		}
+	}
+	if c.Levels != nil {
+		SetLevelMap(c.Levels)
+	}
+
+	for path, w := range applied.retired {
+		if _, ok := b.files[path]; !ok {
+			w.Close()
+		}
+	}
+	applied.retired = make(map[string]io.WriteCloser)
+	for path, w := range applied.files {
+		if _, ok := b.files[path]; !ok {
+			applied.retired[path] = w
+		}
+	}
+	applied.files = b.files
+	applied.loggers = make(map[string]bool, len(loggers))
+	for lname := range loggers {
+		applied.loggers[lname] = true
+	}
+	return nil
+}
+
+func (b *configBuilder) build(name string) (*configHandler, error) {
+	if h, ok := b.handlers[name]; ok {
+		return h, nil
+	}
+	hc, ok := b.cfg.Handlers[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown handler %q", name)
+	}
+	if b.building[name] {
+		return nil, fmt.Errorf("Handler %q includes itself", name)
+	}
+	b.building[name] = true
+
+	flags := 0
+	for _, f := range hc.Flags {
+		flag, ok := flagNames[strings.ToLower(strings.TrimSpace(f))]
+		if !ok {
+			return nil, fmt.Errorf("Unknown flag %q for handler %q", f, name)
+		}
+		flags |= flag
+	}
+	ch := &configHandler{codeinfo: flags&(Lshortfile|Llongfile) != 0}
+
+	typ := strings.ToLower(hc.Type)
+	var w io.Writer
+	var err error
+	switch {
+	case typ == "multi", typ == "journal":
+	case typ == "syslog" && (hc.Network != "" || hc.Address != ""):
+		w, err = b.syslogOutput(hc.Network, hc.Address)
+	default:
+		w, err = b.output(hc.Output)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []HandlerOption
+	if hc.Flags != nil {
+		opts = append(opts, FlagsOpt(flags))
+	}
+	if hc.TimeFormat != "" {
+		opts = append(opts, TimeFormatOpt(hc.TimeFormat))
+	}
+
+	switch typ {
+	case "", "std":
+		if hc.Flags == nil {
+			flags = LstdFlags
+		}
+		ch.h = NewStdFormatter(w, hc.Prefix, flags)
+	case "min":
+		ch.h = NewMinFormatter(w, opts...)
+	case "json":
+		ch.h = NewJSONFormatter(w, opts...)
+	case "logfmt":
+		ch.h = NewLogfmtFormatter(w, opts...)
+	case "template":
+		ch.h = NewTemplateFormatter(w, hc.Template, opts...)
+	case "syslog":
+		ch.h = NewSyslogFormatter(w)
+	case "journal":
+		ch.h = NewJournalHandler()
+	case "multi":
+		hs := make([]Handler, len(hc.Handlers))
+		for i, sub := range hc.Handlers {
+			h, err := b.build(sub)
+			if err != nil {
+				return nil, err
+			}
+			hs[i] = h.h
+			ch.codeinfo = ch.codeinfo || h.codeinfo
+		}
+		ch.h = MultiHandler(hs...)
+	default:
+		return nil, fmt.Errorf("Unknown type %q of handler %q", hc.Type, name)
+	}
+
+	if hc.Level != "" {
+		lvl, err := ParseLevel(hc.Level)
+		if err != nil {
+			return nil, err
+		}
+		ch.h = LvlFilterHandler(lvl, ch.h)
+	}
+
+	b.building[name] = false
+	b.handlers[name] = ch
+	return ch, nil
+}
+
+// Find the output writer. Files still open from applied Configs are reused.
+func (b *configBuilder) output(output string) (io.Writer, error) {
+	switch output {
+	case "", "stderr":
+		return os.Stderr, nil
+	case "stdout":
+		return os.Stdout, nil
+	case "discard":
+		return ioutil.Discard, nil
+	}
+	return b.reuse(output, func() (io.WriteCloser, error) {
+		return NewRotatingFileWriter(output)
+	})
+}
+
+// Connect to a syslog daemon. Connections still open from applied Configs are reused.
+func (b *configBuilder) syslogOutput(network, address string) (io.Writer, error) {
+	switch network {
+	case "":
+		network = "udp"
+	case "local":
+		network, address = "", ""
+	}
+	return b.reuse("syslog:"+network+":"+address, func() (io.WriteCloser, error) {
+		return NewSyslogWriter(network, address)
+	})
+}
+
+func (b *configBuilder) reuse(key string, open func() (io.WriteCloser, error)) (io.Writer, error) {
+	if w, ok := b.files[key]; ok {
+		return w, nil
+	}
+	w, ok := applied.files[key]
+	if !ok {
+		w, ok = applied.retired[key]
+	}
+	if !ok {
+		var err error
+		if w, err = open(); err != nil {
+			return nil, err
+		}
+		b.opened = append(b.opened, w)
+	}
+	b.files[key] = w
+	return w, nil
+}
+
+// DoCodeInfo can lose a race with other config changes. Retry until it doesn't.
+func doCodeInfo(l *Logger, doCode bool) {
+	for !l.DoCodeInfo(doCode) {
+	}
+}
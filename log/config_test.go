@@ -0,0 +1,179 @@
+package log_test
+
+import (
+	"encoding/json"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func applyConfig(t *testing.T, js string) error {
+	var cfg log.Config
+	if err := json.Unmarshal([]byte(js), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg.Apply()
+}
+
+func TestConfigApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file1, file2 := filepath.Join(dir, "1.log"), filepath.Join(dir, "2.log")
+
+	cfg := `{
+	"handlers": {
+		"one":   {"type": "std", "output": "` + file1 + `", "flags": ["level", "name"], "level": "warning"},
+		"two":   {"type": "logfmt", "output": "` + file2 + `", "flags": ["level"]},
+		"multi": {"type": "multi", "handlers": ["one", "two"]}
+	},
+	"loggers": {"config/test": "multi"},
+	"levels": {"config/test": "debug"}
+}`
+	if err := applyConfig(t, cfg); err != nil {
+		t.Fatal(err)
+	}
+	l := log.GetLogger("config/test/child")
+	l.ERROR("error")
+	l.DEBUG("debug")
+
+	if err := applyConfig(t, `{"handlers": {"x": {"type": "nosuch"}}, "loggers": {"config/test": "x"}}`); err == nil {
+		t.Error("Invalid config applied")
+	}
+
+	// Swap while logging. Each event goes to a single file.
+	swap := func(file string) {
+		if err := applyConfig(t, `{"handlers": {"h": {"type": "min", "output": "`+file+`"}}, "loggers": {"config/test": "h"}}`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	swap(file2)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			l.ERROR("swapping")
+		}
+		wg.Done()
+	}()
+	for i := 0; i < 10; i++ {
+		file := file1
+		if i%2 == 1 {
+			file = file2
+		}
+		swap(file)
+	}
+	wg.Wait()
+	if l.Level() != syslog.LOG_DEBUG {
+		t.Error("Levels changed without levels in config")
+	}
+	applyConfig(t, `{"loggers": {}}`)
+	if log.GetLogger("config/test").Handler() != nil {
+		t.Error("Handler not removed")
+	}
+
+	out1, _ := ioutil.ReadFile(file1)
+	out2, _ := ioutil.ReadFile(file2)
+	lines1 := strings.Split(string(out1), "\n")
+	if lines1[0] != "<3>(config/test/child) error" || !strings.HasPrefix(string(out2), "level=error msg=error\nlevel=debug msg=debug\n") {
+		t.Errorf("Unexpected output:\n%s\n%s", out1, out2)
+	}
+	if n := strings.Count(string(out1), "swapping") + strings.Count(string(out2), "swapping"); n != 1000 {
+		t.Errorf("Expected 1000 events logged while swapping, got %d", n)
+	}
+}
+
+// Old Handlers can log until the next Apply
+func TestConfigRetiredFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file1, file2 := filepath.Join(dir, "1.log"), filepath.Join(dir, "2.log")
+	cfg := func(file string) string {
+		return `{"handlers": {"h": {"type": "min", "output": "` + file + `"}}, "loggers": {"config/retired": "h"}}`
+	}
+	defer applyConfig(t, `{"loggers": {}}`)
+
+	if err := applyConfig(t, cfg(file1)); err != nil {
+		t.Fatal(err)
+	}
+	l := log.GetLogger("config/retired")
+	old := l.Handler()
+	if err := applyConfig(t, cfg(file2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.NewLogger(syslog.LOG_DEBUG, old).Log(syslog.LOG_INFO, "late"); err != nil {
+		t.Errorf("Old Handler failed: %v", err)
+	}
+	if err := applyConfig(t, cfg(file2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.NewLogger(syslog.LOG_DEBUG, old).Log(syslog.LOG_INFO, "too late"); err == nil {
+		t.Error("Old file not closed")
+	}
+	if out, _ := ioutil.ReadFile(file1); string(out) != "<6>late\n" {
+		t.Errorf("Unexpected output: %q", out)
+	}
+}
+
+func TestConfigCodeInfo(t *testing.T) {
+	cfg := func(flags string) string {
+		return `{"handlers": {"h": {"type": "std", "output": "discard", "flags": [` + flags + `]}}, "loggers": {"config/codeinfo": "h"}}`
+	}
+	defer applyConfig(t, `{"loggers": {}}`)
+	l := log.GetLogger("config/codeinfo")
+
+	if err := applyConfig(t, cfg(`"shortfile"`)); err != nil {
+		t.Fatal(err)
+	}
+	if !l.DoingCodeInfo() {
+		t.Error("Code info not enabled")
+	}
+	if err := applyConfig(t, cfg(`"level"`)); err != nil {
+		t.Fatal(err)
+	}
+	if l.DoingCodeInfo() {
+		t.Error("Code info not disabled")
+	}
+	applyConfig(t, cfg(`"longfile"`))
+	applyConfig(t, `{"loggers": {}}`)
+	if l.DoingCodeInfo() {
+		t.Error("Code info not disabled for removed Logger")
+	}
+}
+
+func TestConfigSyslogNetwork(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	defer applyConfig(t, `{"loggers": {}}`)
+
+	cfg := `{"handlers": {"h": {"type": "syslog", "address": "` + pc.LocalAddr().String() + `"}}, "loggers": {"config/syslog": "h"}}`
+	if err := applyConfig(t, cfg); err != nil {
+		t.Fatal(err)
+	}
+	log.GetLogger("config/syslog").ERROR("shipped")
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, " shipped") {
+		t.Errorf("Unexpected message %q", msg)
+	}
+}
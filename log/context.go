@@ -0,0 +1,150 @@
+package log
+
+import (
+	"context"
+	"github.com/One-com/gone/log/syslog"
+	"sync"
+)
+
+// Carrying Loggers and request scoped KV data in a context.Context
+
+type loggerKey struct{}
+
+type contextKey struct {
+	name string
+	key  interface{}
+}
+
+var (
+	ctxmu   sync.RWMutex
+	ctxkeys []contextKey
+)
+
+// NewContext returns a copy of ctx carrying the Logger l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the Logger carried by ctx - or the default Logger if none -
+// with the values of any registered context keys found in ctx added as KV data.
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(loggerKey{}).(*Logger)
+	if !ok {
+		l = defaultLogger
+	}
+	return l.WithContext(ctx)
+}
+
+// RegisterContextKey makes the context aware functions add the value stored in
+// a context.Context under key as KV data with the given name.
+// Use it for request scoped data like request or trace ID's.
+func RegisterContextKey(name string, key interface{}) {
+	ctxmu.Lock()
+	defer ctxmu.Unlock()
+	for i := range ctxkeys {
+		if ctxkeys[i].key == key {
+			ctxkeys[i].name = name
+			return
+		}
+	}
+	ctxkeys = append(ctxkeys, contextKey{name: name, key: key})
+}
+
+// UnregisterContextKey removes a key registered with RegisterContextKey.
+func UnregisterContextKey(key interface{}) {
+	ctxmu.Lock()
+	defer ctxmu.Unlock()
+	for i := range ctxkeys {
+		if ctxkeys[i].key == key {
+			ctxkeys = append(ctxkeys[:i], ctxkeys[i+1:]...)
+			return
+		}
+	}
+}
+
+// KV data for the registered keys having values in ctx
+func contextKV(ctx context.Context) (kv []interface{}) {
+	ctxmu.RLock()
+	defer ctxmu.RUnlock()
+	for _, k := range ctxkeys {
+		if v := ctx.Value(k.key); v != nil {
+			kv = append(kv, k.name, v)
+		}
+	}
+	return
+}
+
+// WithContext returns a child Logger (like With()) with the values of any registered context keys
+// found in ctx added as KV data. If there are none, the Logger itself is returned.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	kv := contextKV(ctx)
+	if kv == nil {
+		return l
+	}
+	return l.With(kv...)
+}
+
+// LogContext is like Log(), but adds the values of any registered context keys found in ctx
+// as KV data - like WithContext(ctx), in the same order.
+func (l *Logger) LogContext(ctx context.Context, level syslog.Priority, msg string, kv ...interface{}) (err error) {
+	if l.Does(level) {
+		err = l.WithContext(ctx).log(level, msg, kv...)
+	}
+	return
+}
+
+// ALERTContext - Log a message and optional KV values at syslog ALERT level, adding the values of registered context keys in ctx.
+func (l *Logger) ALERTContext(ctx context.Context, msg string, kv ...interface{}) {
+	lvl := syslog.LOG_ALERT
+	if l.Does(lvl) {
+		l.WithContext(ctx).log(lvl, msg, kv...)
+	}
+}
+
+// CRITContext - Log a message and optional KV values at syslog CRIT level, adding the values of registered context keys in ctx.
+func (l *Logger) CRITContext(ctx context.Context, msg string, kv ...interface{}) {
+	lvl := syslog.LOG_CRIT
+	if l.Does(lvl) {
+		l.WithContext(ctx).log(lvl, msg, kv...)
+	}
+}
+
+// ERRORContext - Log a message and optional KV values at syslog ERROR level, adding the values of registered context keys in ctx.
+func (l *Logger) ERRORContext(ctx context.Context, msg string, kv ...interface{}) {
+	lvl := syslog.LOG_ERROR
+	if l.Does(lvl) {
+		l.WithContext(ctx).log(lvl, msg, kv...)
+	}
+}
+
+// WARNContext - Log a message and optional KV values at syslog WARN level, adding the values of registered context keys in ctx.
+func (l *Logger) WARNContext(ctx context.Context, msg string, kv ...interface{}) {
+	lvl := syslog.LOG_WARN
+	if l.Does(lvl) {
+		l.WithContext(ctx).log(lvl, msg, kv...)
+	}
+}
+
+// NOTICEContext - Log a message and optional KV values at syslog NOTICE level, adding the values of registered context keys in ctx.
+func (l *Logger) NOTICEContext(ctx context.Context, msg string, kv ...interface{}) {
+	lvl := syslog.LOG_NOTICE
+	if l.Does(lvl) {
+		l.WithContext(ctx).log(lvl, msg, kv...)
+	}
+}
+
+// INFOContext - Log a message and optional KV values at syslog INFO level, adding the values of registered context keys in ctx.
+func (l *Logger) INFOContext(ctx context.Context, msg string, kv ...interface{}) {
+	lvl := syslog.LOG_INFO
+	if l.Does(lvl) {
+		l.WithContext(ctx).log(lvl, msg, kv...)
+	}
+}
+
+// DEBUGContext - Log a message and optional KV values at syslog DEBUG level, adding the values of registered context keys in ctx.
+func (l *Logger) DEBUGContext(ctx context.Context, msg string, kv ...interface{}) {
+	lvl := syslog.LOG_DEBUG
+	if l.Does(lvl) {
+		l.WithContext(ctx).log(lvl, msg, kv...)
+	}
+}
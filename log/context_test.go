@@ -0,0 +1,38 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"testing"
+)
+
+type reqIDKey struct{}
+
+func TestContext(t *testing.T) {
+	log.RegisterContextKey("req", reqIDKey{})
+	defer log.UnregisterContextKey(reqIDKey{})
+
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_INFO, log.NewStdFormatter(&b, "", 0)).With("svc", "x")
+
+	ctx := context.WithValue(context.Background(), reqIDKey{}, 42)
+	ctx = log.NewContext(ctx, l)
+
+	log.FromContext(ctx).INFO("from", "k", 1)
+	l.LogContext(ctx, syslog.LOG_INFO, "direct", "k", 2)
+	l.LogContext(ctx, syslog.LOG_DEBUG, "filtered")
+	l.LogContext(context.Background(), syslog.LOG_INFO, "none")
+	l.WARNContext(ctx, "warn", "k", 3)
+	l.DEBUGContext(ctx, "filtered")
+
+	expected := "from req=42 svc=x k=1\ndirect req=42 svc=x k=2\nnone svc=x\nwarn req=42 svc=x k=3\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+
+	if log.FromContext(context.Background()) != log.Default() {
+		t.Error("Expected default Logger from empty context")
+	}
+}
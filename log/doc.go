@@ -98,6 +98,12 @@ The other is to pick an comma-ok style log function:
 
     	if f,ok := l.DEBUGok(); ok  { f("heavy", "fib123", fib(123)) }
 
+On hot paths, the boxing of key/value data in interface{} can be avoided by logging typed fields. LogFields() doesn't allocate if the level is disabled or the event is formatted by the JSON formatter:
+
+    	l.LogFields(syslog.LOG_INFO, "request", log.String("path", path), log.Int("status", 200), log.Dur("took", d))
+
+Fields can also be given to With() and the level functions in place of key/value pairs.
+
 Sometimes it can be repetitive to make a lot of log statements logging many attributes of the same kinda of object by explicitly accessing every attribute. To make that simpler, every object can implement the Logable interface by creating a LogValues() function returning the attributes to be logged (with keys). The object can then be logged by directly providing it as an argument to a log function:
 
    type Request struct {
@@ -139,6 +145,9 @@ The Python behaviour is to send the event to all Handlers found in the Logger tr
 	     mylib.FuncWhichLogsOnError()
 	}
 
+Levels for the whole Logger tree can be set from a single spec, which also applies to Loggers created later:
+
+	log.SetLevels("warn,mylib=error,mylib/db=debug")
 
 Happy logging.
 
@@ -0,0 +1,86 @@
+package log
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Rendering of wrapped errors (errors.Unwrap and errors.Join style)
+
+// unwrapErrors returns the errors directly wrapped by err
+func unwrapErrors(err error) (errs []error) {
+	defer func() {
+		// Unwrap on a nil pointer receiver
+		if panicVal := recover(); panicVal != nil {
+			if v := reflect.ValueOf(err); v.Kind() == reflect.Ptr && v.IsNil() {
+				errs = nil
+			} else {
+				panic(panicVal)
+			}
+		}
+	}()
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := x.Unwrap(); cause != nil {
+			errs = []error{cause}
+		}
+	case interface{ Unwrap() []error }:
+		for _, cause := range x.Unwrap() {
+			if cause != nil {
+				errs = append(errs, cause)
+			}
+		}
+	}
+	return
+}
+
+// errorCauseKeyvals creates extra key/value pairs for the causes of error values
+// in keyvals, named <key>.cause.<n> - numbered depth first.
+func errorCauseKeyvals(keyvals []interface{}) (kv []interface{}) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		err, ok := keyvals[i+1].(error)
+		if !ok {
+			continue
+		}
+		key, ok := keyvals[i].(string)
+		if !ok {
+			continue
+		}
+		var n int
+		kv = appendErrorCauses(kv, key+".cause.", &n, err)
+	}
+	return
+}
+
+// withErrorCauses returns keyvals with the pairs from errorCauseKeyvals appended.
+// For formats rendering errors as their message.
+func withErrorCauses(keyvals []interface{}) []interface{} {
+	causes := errorCauseKeyvals(keyvals)
+	if causes == nil {
+		return keyvals
+	}
+	return append(keyvals[:len(keyvals):len(keyvals)], causes...)
+}
+
+func appendErrorCauses(kv []interface{}, prefix string, n *int, err error) []interface{} {
+	for _, cause := range unwrapErrors(err) {
+		kv = append(kv, prefix+strconv.Itoa(*n), errorMessage(cause))
+		*n++
+		kv = appendErrorCauses(kv, prefix, n, cause)
+	}
+	return kv
+}
+
+// errorMessage calls Error(), handling nil pointer receivers
+func errorMessage(err error) (s string) {
+	defer func() {
+		if panicVal := recover(); panicVal != nil {
+			if v := reflect.ValueOf(err); v.Kind() == reflect.Ptr && v.IsNil() {
+				s = "NULL"
+			} else {
+				panic(panicVal)
+			}
+		}
+	}()
+	return err.Error()
+}
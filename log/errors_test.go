@@ -0,0 +1,119 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type joinedErrors []error
+
+func (j joinedErrors) Error() string   { return "joined" }
+func (j joinedErrors) Unwrap() []error { return j }
+
+func testError() error {
+	base := errors.New("base")
+	return fmt.Errorf("outer: %w", joinedErrors{fmt.Errorf("middle: %w", base), errors.New("other")})
+}
+
+func TestErrorChainRendering(t *testing.T) {
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewStdFormatter(&b, "", 0))
+	l.ERROR("failed", "err", testError())
+
+	expected := `failed err="outer: joined" err.cause.0=joined err.cause.1="middle: base" err.cause.2=base err.cause.3=other` + "\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+
+	b.Reset()
+	l.SetHandler(log.NewJSONFormatter(&b))
+	l.ERROR("failed", "err", testError())
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(m["err"])
+	expected = `{"causes":[{"causes":[{"causes":["base"],"error":"middle: base"},"other"],"error":"joined"}],"error":"outer: joined"}`
+	if string(got) != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	b.Reset()
+	l.SetHandler(log.NewLogfmtFormatter(&b, log.FlagsOpt(0)))
+	l.ERROR("failed", "err", testError())
+	expected = `msg=failed err="outer: joined" err.cause.0=joined err.cause.1="middle: base" err.cause.2=base err.cause.3=other` + "\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+
+	b.Reset()
+	l.SetHandler(log.NewTemplateFormatter(&b, "%msg %kv"))
+	l.ERROR("failed", "err", testError())
+	expected = `failed err="outer: joined" err.cause.0=joined err.cause.1="middle: base" err.cause.2=base err.cause.3=other` + "\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+
+	b.Reset()
+	l.SetHandler(log.NewSyslogFormatter(&b))
+	l.ERROR("failed", "err", testError(), "plain", "p")
+	expected = `[kv@32473 err="outer: joined" plain="p" err.cause.0="joined" err.cause.1="middle: base" err.cause.2="base" err.cause.3="other"] failed` + "\n"
+	if !strings.HasSuffix(b.String(), expected) {
+		t.Errorf("Expected suffix %q, got %q", expected, b.String())
+	}
+}
+
+func logWithStack(l *log.Logger) {
+	l.CRIT("with stack")
+}
+
+func TestStackTrace(t *testing.T) {
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewStdFormatter(&b, "", 0))
+	l.DoStackTrace(syslog.LOG_CRIT, true)
+
+	l.ERROR("no stack")
+	logWithStack(l)
+
+	lines := strings.Split(b.String(), "\n")
+	if lines[0] != "no stack" || lines[1] != "with stack" {
+		t.Fatalf("Unexpected output: %q", b.String())
+	}
+	if !strings.HasSuffix(lines[2], "log_test.logWithStack") ||
+		!regexp.MustCompile(`^\t\t.*/errors_test.go:\d+$`).MatchString(lines[3]) ||
+		!strings.HasSuffix(lines[4], "log_test.TestStackTrace") {
+		t.Errorf("Unexpected stack trace: %q", b.String())
+	}
+
+	b.Reset()
+	l.SetHandler(log.NewTemplateFormatter(&b, "%msg"))
+	logWithStack(l)
+	lines = strings.Split(b.String(), "\n")
+	if lines[0] != "with stack" || !strings.HasSuffix(lines[1], "log_test.logWithStack") {
+		t.Errorf("Unexpected stack trace: %q", b.String())
+	}
+
+	b.Reset()
+	l.SetHandler(log.NewJSONFormatter(&b))
+	logWithStack(l)
+	var m struct {
+		Stack []struct {
+			Func string
+			Line int
+		} `json:"_stack"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Stack) < 2 || !strings.HasSuffix(m.Stack[0].Func, "logWithStack") || m.Stack[0].Line == 0 {
+		t.Errorf("Unexpected stack trace: %s", b.String())
+	}
+}
@@ -21,7 +21,9 @@ func init() {
 
 func getPoolEvent(l syslog.Priority, name string, msg string) *event {
 	e := evpool.Get().(*event)
+	fields := e.fields[:0] // keep the capacity
 	*e = event{}
+	e.fields = fields
 	e.Lvl = l
 	e.Msg = msg
 	e.Name = name
@@ -52,25 +54,57 @@ type event struct {
 	fok  bool
 	file string
 	line int
+
+	stack []uintptr // program counters of the call stack, if captured
+
+	fields    []Field // typed fields, see Field
+	ctxfields int     // the number of leading fields from the Logger context
+}
+
+// clone creates a copy of the event not belonging to the event pool, which
+// can outlive the Log() call. The KV data slice is copied, but values are not.
+// (Lazy values are thus still evaluated when formatted).
+// The copy is timestamped if it isn't already.
+func (e *event) clone() *event {
+	c := new(event)
+	*c = *e
+	if !c.tok {
+		c.time = time.Now()
+		c.tok = true
+	}
+	if e.Data != nil {
+		c.Data = make([]interface{}, len(e.Data))
+		copy(c.Data, e.Data)
+	}
+	if len(e.fields) > 0 {
+		// fields belong to the pooled event
+		c.fields = make([]Field, len(e.fields))
+		copy(c.fields, e.fields)
+	} else {
+		c.fields = nil
+	}
+	return c
 }
 
 // EventKeyNames holds keynames for fixed event fields, when needed (such as in JSON)
 type EventKeyNames struct {
-	Lvl  string
-	Name string
-	Time string
-	Msg  string
-	File string
-	Line string
+	Lvl   string
+	Name  string
+	Time  string
+	Msg   string
+	File  string
+	Line  string
+	Stack string
 }
 
 var defaultKeyNames = &EventKeyNames{
-	Lvl:  "_lvl",
-	Name: "_name",
-	Time: "_ts",
-	Msg:  "_msg",
-	File: "_file",
-	Line: "_line",
+	Lvl:   "_lvl",
+	Name:  "_name",
+	Time:  "_ts",
+	Msg:   "_msg",
+	File:  "_file",
+	Line:  "_line",
+	Stack: "_stack",
 }
 
 // Level returns the log level of the event
@@ -110,6 +144,27 @@ func (e Event) FileInfo() (string, int) {
 	return e.file, e.line
 }
 
+// Max number of stack frames captured
+const maxStackDepth = 64
+
+// StackTrace returns the stack frames of the go-routine logging the event, starting
+// with the caller of the log function. It's empty unless the Logger does stack traces
+// at the level of the event. (See Logger.DoStackTrace)
+func (e Event) StackTrace() (frames []runtime.Frame) {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	iter := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+	return
+}
+
 // The primary event constructor. Creates a new log event.
 // The event is timestamped if needed
 // Code info file/line is recorded if needed
@@ -118,7 +173,7 @@ func (l *Logger) newEvent(calldepth int, level syslog.Priority, msg string, data
 
 	e := getPoolEvent(level, l.name, msg)
 
-	dt, dc := l.cfg.doing()
+	dt, dc, ds := l.cfg.doing(level)
 	if dt {
 		e.time = time.Now()
 		e.tok = true
@@ -131,6 +186,15 @@ func (l *Logger) newEvent(calldepth int, level syslog.Priority, msg string, data
 			e.fok = true
 		}
 	}
+	if ds {
+		pcs := make([]uintptr, maxStackDepth)
+		e.stack = pcs[:runtime.Callers(calldepth+3, pcs)]
+	}
+
+	for parent := l; parent != nil; parent = parent.cparent {
+		e.fields = append(e.fields, parent.fields...)
+	}
+	e.ctxfields = len(e.fields)
 
 	if l.cparent == nil && l.data == nil {
 		e.Data = data
@@ -0,0 +1,234 @@
+package log
+
+import (
+	"github.com/One-com/gone/log/syslog"
+	"math"
+	"time"
+)
+
+type fieldType uint8
+
+const (
+	fieldAny fieldType = iota
+	fieldString
+	fieldInt
+	fieldInt64
+	fieldUint64
+	fieldFloat64
+	fieldBool
+	fieldDuration
+	fieldTime
+	fieldError
+)
+
+// Field is a typed key/value pair. Fields are created with String(), Int(), Dur(), Err() ...
+//
+// Fields can be passed to Log(), INFO() ... and With() in place of a key/value pair:
+//
+//	l.INFO("request", "path", path, log.Int("status", 200), log.Dur("took", d))
+//
+// This still boxes the Field in an interface{}, which allocates. To avoid any allocation
+// use LogFields() or a Logger created by With() with fields, which keeps them typed
+// until formatted. The JSON formatter encodes such typed fields without
+// reflection or allocation. Other Handlers see fields as normal key/value pairs.
+type Field struct {
+	Key string
+	typ fieldType
+	num uint64
+	str string
+	any interface{}
+}
+
+// String creates a string Field
+func String(key, value string) Field {
+	return Field{Key: key, typ: fieldString, str: value}
+}
+
+// Int creates an int Field
+func Int(key string, value int) Field {
+	return Field{Key: key, typ: fieldInt, num: uint64(value)}
+}
+
+// Int64 creates an int64 Field
+func Int64(key string, value int64) Field {
+	return Field{Key: key, typ: fieldInt64, num: uint64(value)}
+}
+
+// Uint64 creates an uint64 Field
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, typ: fieldUint64, num: value}
+}
+
+// Float64 creates a float64 Field
+func Float64(key string, value float64) Field {
+	return Field{Key: key, typ: fieldFloat64, num: math.Float64bits(value)}
+}
+
+// Bool creates a bool Field
+func Bool(key string, value bool) Field {
+	var n uint64
+	if value {
+		n = 1
+	}
+	return Field{Key: key, typ: fieldBool, num: n}
+}
+
+// Dur creates a time.Duration Field
+func Dur(key string, value time.Duration) Field {
+	return Field{Key: key, typ: fieldDuration, num: uint64(value)}
+}
+
+// Time creates a time.Time Field
+func Time(key string, value time.Time) Field {
+	if y := value.Year(); y < 1678 || y > 2261 {
+		// Outside the range of UnixNano()
+		return Field{Key: key, typ: fieldAny, any: value}
+	}
+	return Field{Key: key, typ: fieldTime, num: uint64(value.UnixNano()), any: value.Location()}
+}
+
+// Err creates an error Field
+func Err(key string, err error) Field {
+	return Field{Key: key, typ: fieldError, any: err}
+}
+
+// Any creates a Field with any value, like a normal key/value pair.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, typ: fieldAny, any: value}
+}
+
+// Value returns the value of the Field
+func (f Field) Value() interface{} {
+	switch f.typ {
+	case fieldString:
+		return f.str
+	case fieldInt:
+		return int(f.num)
+	case fieldInt64:
+		return int64(f.num)
+	case fieldUint64:
+		return f.num
+	case fieldFloat64:
+		return math.Float64frombits(f.num)
+	case fieldBool:
+		return f.num != 0
+	case fieldDuration:
+		return time.Duration(f.num)
+	case fieldTime:
+		return f.time()
+	}
+	return f.any
+}
+
+func (f Field) time() time.Time {
+	return time.Unix(0, int64(f.num)).In(f.any.(*time.Location))
+}
+
+// Append the key/value pairs of fields to kv
+func appendFields(kv []interface{}, fields []Field) []interface{} {
+	for _, f := range fields {
+		kv = append(kv, f.Key, f.Value())
+	}
+	return kv
+}
+
+// Handlers encoding typed fields them selves. Other Handlers get events with fields as key/value pairs.
+type fieldHandler interface {
+	encodesFields()
+}
+
+// materialize moves typed fields to the KV data. Fields from the Logger context
+// go before the KV data, like the untyped context KV data.
+func (e *event) materialize() {
+	if len(e.fields) == 0 {
+		return
+	}
+	data := make([]interface{}, 0, len(e.Data)+2*len(e.fields))
+	data = appendFields(data, e.fields[:e.ctxfields])
+	data = append(data, e.Data...)
+	e.Data = appendFields(data, e.fields[e.ctxfields:])
+	e.fields = e.fields[:0]
+	e.ctxfields = 0
+}
+
+// LogFields logs a message with typed fields at level. It doesn't allocate if the
+// level is disabled - and neither when formatted with the JSON formatter.
+func (l *Logger) LogFields(level syslog.Priority, msg string, fields ...Field) (err error) {
+	if l.Does(level) {
+		e := l.newEvent(0, level, msg, nil)
+		e.fields = append(e.fields, fields...)
+		err = l.h.Log(e)
+	}
+	return
+}
+
+// The number of elements of kv starting at a key position i. Fields and leading Logables
+// are single elements, otherwise it's a key/value pair.
+func span(kv []interface{}, i int, leading bool) int {
+	if _, ok := kv[i].(Field); ok {
+		return 1
+	}
+	if _, ok := kv[i].(Logable); ok && leading {
+		return 1
+	}
+	if i+1 < len(kv) {
+		return 2
+	}
+	return 1
+}
+
+// Index of the first Field in key position in kv, or -1
+func firstField(kv []interface{}) int {
+	leading := true
+	for i := 0; i < len(kv); {
+		if _, ok := kv[i].(Field); ok {
+			return i
+		}
+		n := span(kv, i, leading)
+		leading = leading && n == 1
+		i += n
+	}
+	return -1
+}
+
+// expandFields replaces Fields in kv by their key/value pairs.
+func expandFields(kv []interface{}) []interface{} {
+	first := firstField(kv)
+	if first < 0 {
+		return kv
+	}
+	out := make([]interface{}, first, len(kv)+len(kv)-first)
+	copy(out, kv[:first])
+	for i := first; i < len(kv); {
+		if f, ok := kv[i].(Field); ok {
+			out = append(out, f.Key, f.Value())
+			i++
+			continue
+		}
+		n := span(kv, i, false)
+		out = append(out, kv[i:i+n]...)
+		i += n
+	}
+	return out
+}
+
+// splitFields separates Fields in key position from the rest of kv.
+func splitFields(kv []interface{}) (fields []Field, rest []interface{}) {
+	first := firstField(kv)
+	if first < 0 {
+		return nil, kv
+	}
+	rest = make([]interface{}, first, len(kv))
+	copy(rest, kv[:first])
+	for i := first; i < len(kv); {
+		if f, ok := kv[i].(Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+		n := span(kv, i, false)
+		rest = append(rest, kv[i:i+n]...)
+		i += n
+	}
+	return
+}
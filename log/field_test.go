@@ -0,0 +1,79 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFieldsJSON(t *testing.T) {
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewJSONFormatter(&b, log.TimeFormatOpt("static")))
+	l = l.With("ctx", "c", log.Int("n", 1))
+
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("X", 3600))
+	str := "a\"b\\\n\t\b\f\x01<&> \xffæ"
+	l.LogFields(syslog.LOG_INFO, "m <&>",
+		log.String("s", str),
+		log.Float64("f1", 1e-7), log.Float64("f2", 1e21), log.Float64("f3", 3.5),
+		log.Bool("ok", true), log.Dur("d", 1500*time.Millisecond), log.Time("t", ts),
+		log.Err("err", errors.New("failed")), log.Err("nilerr", nil),
+		log.Uint64("u", math.MaxUint64), log.Int64("i", -5), log.Any("x", nil),
+		log.String("n", "last wins"))
+
+	expected, _ := json.Marshal(map[string]interface{}{
+		"_lvl": syslog.LOG_INFO, "_msg": "m <&>", "_name": "", "_ts": "static",
+		"ctx": "c", "s": str, "f1": 1e-7, "f2": 1e21, "f3": 3.5, "ok": true,
+		"d": 1500 * time.Millisecond, "t": ts, "err": "failed", "nilerr": nil,
+		"u": uint64(math.MaxUint64), "i": int64(-5), "x": nil, "n": "last wins",
+	})
+	if b.String() != string(expected)+"\n" {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+
+	// Falling back to encoding/json
+	b.Reset()
+	l.LogFields(syslog.LOG_INFO, "wrapped", log.Err("err", fmt.Errorf("outer: %w", errors.New("inner"))))
+	var m map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(m["err"])
+	if string(got) != `{"causes":["inner"],"error":"outer: inner"}` || m["n"] != 1.0 {
+		t.Errorf("Unexpected output: %s", b.String())
+	}
+}
+
+func TestFieldsKeyvals(t *testing.T) {
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewStdFormatter(&b, "", 0))
+
+	l.INFO("mixed", "a", 1, log.Int("b", 2), "c", 3, log.Dur("d", time.Second))
+	l.With(log.String("svc", "x"), "k", "v").LogFields(syslog.LOG_INFO, "typed", log.Bool("ok", true))
+	l.LogFields(syslog.LOG_DEBUG, "disabled")
+	l.SetLevel(syslog.LOG_INFO)
+	l.LogFields(syslog.LOG_DEBUG, "disabled")
+
+	expected := "mixed a=1 b=2 c=3 d=1s\ntyped svc=x k=v ok=true\ndisabled\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+
+	// Event KV data overrides typed context fields
+	b.Reset()
+	l.SetHandler(log.NewJSONFormatter(&b))
+	l.With(log.String("k", "ctx")).INFO("override", "k", "event")
+	var m map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["k"] != "event" {
+		t.Errorf("Unexpected output: %s", b.String())
+	}
+}
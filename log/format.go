@@ -8,6 +8,7 @@ import (
 	"github.com/go-logfmt/logfmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -138,24 +139,26 @@ func (f *stdformatter) Flags() int {
 // Generate options to create a new Handler
 func (f *stdformatter) AutoColoring() HandlerOption {
 	return func(c CloneableHandler) {
-		var istty bool
 		if o, ok := c.(*stdformatter); ok {
-			w := o.out
-			if tw, ok := w.(MaybeTtyWriter); ok {
-				istty = tw.IsTty()
-			} else {
-				istty = term.IsTty(w)
-			}
-
-			if istty {
-				o.flag = o.flag | Lcolor
-			} else {
-				o.flag = o.flag & ^Lcolor
-			}
+			o.flag = colorFlag(o.flag, o.out)
 		}
 	}
 }
 
+// colorFlag sets or clears Lcolor in flags depending on whether w is a terminal
+func colorFlag(flags int, w io.Writer) int {
+	var istty bool
+	if tw, ok := w.(MaybeTtyWriter); ok {
+		istty = tw.IsTty()
+	} else {
+		istty = term.IsTty(w)
+	}
+	if istty {
+		return flags | Lcolor
+	}
+	return flags & ^Lcolor
+}
+
 // SetFlags creates a HandlerOption to set flags.
 // This is a method wrapper around FlagsOpt to be able to have the swapper
 // call it genericly on different formatters to support
@@ -180,10 +183,16 @@ func (f *stdformatter) SetOutput(w io.Writer) HandlerOption {
 	return OutputOpt(w)
 }
 
-// FlagsOpt - Standard Formatter Option to set flags
+// FlagsOpt - Standard Formatter Option to set flags.
+// Also supported by the logfmt and template formatters.
 func FlagsOpt(flags int) HandlerOption {
 	return func(c CloneableHandler) {
-		if h, ok := c.(*stdformatter); ok {
+		switch h := c.(type) {
+		case *stdformatter:
+			h.flag = flags
+		case *logfmtformatter:
+			h.flag = flags
+		case *templateformatter:
 			h.flag = flags
 		}
 	}
@@ -201,16 +210,27 @@ func PrefixOpt(prefix string) HandlerOption {
 // OutputOpt - Standard Formatter option so set Output
 func OutputOpt(w io.Writer) HandlerOption {
 	return func(c CloneableHandler) {
-		if h, ok := c.(*stdformatter); ok {
+		switch h := c.(type) {
+		case *stdformatter:
+			h.out = w
+		case *syslogformatter:
+			h.out = w
+		case *logfmtformatter:
+			h.out = w
+		case *templateformatter:
 			h.out = w
 		}
 	}
 }
 
-// LevelPrefixOpt - Standard Formatter option to set LevelPrefixes
+// LevelPrefixOpt - Standard Formatter option to set LevelPrefixes.
+// For the template formatter it sets the strings for %level
 func LevelPrefixOpt(arr *[8]string) HandlerOption {
 	return func(c CloneableHandler) {
-		if h, ok := c.(*stdformatter); ok {
+		switch h := c.(type) {
+		case *stdformatter:
+			h.pfxarr = arr
+		case *templateformatter:
 			h.pfxarr = arr
 		}
 	}
@@ -273,6 +293,10 @@ func (f *stdformatter) Log(e Event) error {
 	if len(e.Data) > 0 {
 		xbuf = append(xbuf, ' ')
 		marshalKeyvals(&buf.Buffer, e.Data...)
+		if causes := errorCauseKeyvals(e.Data); causes != nil {
+			buf.WriteByte(' ')
+			marshalKeyvals(&buf.Buffer, causes...)
+		}
 		xbuf = append(xbuf, buf.Buffer.Bytes()...)
 	}
 
@@ -281,6 +305,9 @@ func (f *stdformatter) Log(e Event) error {
 		xbuf = append(xbuf, '\n')
 	}
 
+	// Any stack trace goes on the following lines
+	xbuf = appendStackTrace(xbuf, e)
+
 	// Now write the message to the tree of chained writers.
 	// If the tree root is a EventWriter, provide the orignal event too.
 	var err error
@@ -310,12 +337,62 @@ func marshalKeyvals(w io.Writer, keyvals ...interface{}) {
 	if len(keyvals) == 0 {
 		return
 	}
-	enc := logfmt.NewEncoder(w)
+	encodeKeyvals(logfmt.NewEncoder(w), keyvals...)
+}
+
+// nestedKeyvals flattens a redacted map value to pairs with keys prefixed by key - sorted by key
+// to give a stable output.
+func nestedKeyvals(key string, kv redactedKV) []interface{} {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]interface{}, 0, len(kv)*2)
+	for _, k := range keys {
+		out = append(out, key+"."+k, kv[k])
+	}
+	return out
+}
+
+// flatKeyvals returns keyvals with redacted map values flattened by nestedKeyvals.
+// For formats rendering values as plain strings.
+func flatKeyvals(keyvals []interface{}) []interface{} {
+	var out []interface{}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		r, ok := keyvals[i+1].(redactedKV)
+		key, kok := keyvals[i].(string)
+		if !ok || !kok {
+			if out != nil {
+				out = append(out, keyvals[i], keyvals[i+1])
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]interface{}, i, len(keyvals)+2*len(r))
+			copy(out, keyvals[:i])
+		}
+		out = append(out, flatKeyvals(nestedKeyvals(key, r))...)
+	}
+	if out == nil {
+		return keyvals
+	}
+	return out
+}
+
+// encodeKeyvals encodes keyvals, trying to save what's possible of bad keys and values.
+func encodeKeyvals(enc *logfmt.Encoder, keyvals ...interface{}) {
 	for i := 0; i < len(keyvals); i += 2 {
 		k, v := keyvals[i], keyvals[i+1]
 		if l, ok := v.(Lazy); ok {
 			v = l.evaluate()
 		}
+		if r, ok := v.(redactedKV); ok {
+			if key, ok := k.(string); ok {
+				encodeKeyvals(enc, nestedKeyvals(key, r)...)
+				continue
+			}
+		}
 		err := enc.EncodeKeyval(k, v)
 		if err != nil {
 			// Try save the error
@@ -342,7 +419,6 @@ func marshalKeyvals(w io.Writer, keyvals ...interface{}) {
 			enc.EncodeKeyval("ERROR", "ERROR")
 		}
 	}
-	return
 }
 
 func (f *stdformatter) formatHeader(buf *[]byte, level syslog.Priority, t time.Time, name string, file string, line int) {
@@ -418,3 +494,17 @@ func (f *stdformatter) formatHeader(buf *[]byte, level syslog.Priority, t time.T
 	}
 
 }
+
+// appendStackTrace appends any stack trace of e - formatted like a panic
+func appendStackTrace(xbuf []byte, e Event) []byte {
+	for _, frame := range e.StackTrace() {
+		xbuf = append(xbuf, '\t')
+		xbuf = append(xbuf, frame.Function...)
+		xbuf = append(xbuf, "\n\t\t"...)
+		xbuf = append(xbuf, frame.File...)
+		xbuf = append(xbuf, ':')
+		itoa(&xbuf, frame.Line, -1)
+		xbuf = append(xbuf, '\n')
+	}
+	return xbuf
+}
@@ -1,3 +1,7 @@
 module github.com/One-com/gone/log
 
-go 1.12
+go 1.21
+
+require github.com/go-logfmt/logfmt v0.6.1
+
+require github.com/google/go-cmp v0.7.0 // indirect
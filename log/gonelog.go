@@ -33,6 +33,11 @@ const (
 
 	maskDefObl uint32 = 0x00000100 // Generate Print*() events despite log level.
 
+	// 3 bit stack trace level, 1 bit dostack
+	stackshift          = 9
+	maskStackLvl uint32 = 0x00000E00 // The most verbose level at which to capture stack traces.
+	maskDoStack  uint32 = 0x00001000 // capture stack traces
+
 	// The default logger has default level and Print*() logging will *not* obey levels.
 	defConfig uint32 = (uint32(LvlDEFAULT) << levelshift) | uint32(LvlDEFAULT) | maskDefObl
 )
@@ -75,6 +80,8 @@ type Logger struct {
 
 	// K/V Attributes common to all events logged ... Using a slice instead of map for speed
 	data []interface{}
+	// Typed fields common to all events logged
+	fields []Field
 }
 
 // NewLogger creates a new unamed Logger out side of the named Logger hierarchy.
@@ -143,6 +150,12 @@ func (l *Logger) SetHandler(h Handler) {
 	l.h.SwapHandler(h)
 }
 
+// Handler returns the Handler set on this Logger - or nil if events are
+// passed to the parent Logger.
+func (l *Logger) Handler() Handler {
+	return l.h.handler()
+}
+
 // ApplyHandlerOptions clones the current Handles and tries to apply the supplied
 // HandlerOptions to the clone - then swaps in the clone atomically to not loose
 // Log events.
@@ -164,6 +177,7 @@ func (l *Logger) AutoColoring() {
 // With ties a sub-context to the Logger and create a new logger which will log
 // the supplied K/V values as context with every log event.
 func (l *Logger) With(kv ...interface{}) *Logger {
+	fields, kv := splitFields(kv)
 	d := normalize(kv)
 	// copy the pointers to handler and config to ease access later
 	// For all purposes except data, this child will be the same as it's cparent.
@@ -176,6 +190,7 @@ func (l *Logger) With(kv ...interface{}) *Logger {
 		// Using the extra capacity without copying risks a data race that
 		// would violate the Logger interface contract.
 		data:    d[:len(d):len(d)],
+		fields:  fields,
 		cparent: l,
 	}
 	return new
@@ -321,6 +336,31 @@ func (l *Logger) DefaultLevel() syslog.Priority {
 	return l.cfg.defaultLevel()
 }
 
+// DoStackTrace tries to turn on or off capturing the stack trace of events logged
+// at level or more severe.
+// It can fail if some other go-routine simultaneous is manipulating the config.
+// Returning whether the change was successful
+func (l *Logger) DoStackTrace(level syslog.Priority, doStack bool) bool {
+	if level > syslog.LOG_DEBUG {
+		level = syslog.LOG_DEBUG
+	}
+	c := atomic.LoadUint32(&l.cfg.config)
+	n := (c & ^maskStackLvl) | (uint32(level) << stackshift)
+	if doStack {
+		n |= maskDoStack
+	} else {
+		n &^= maskDoStack
+	}
+	return atomic.CompareAndSwapUint32(&l.cfg.config, c, n)
+}
+
+// DoingStackTrace returns the level at or below which stack traces are captured,
+// and whether they are captured at all.
+func (l *Logger) DoingStackTrace() (syslog.Priority, bool) {
+	c := atomic.LoadUint32(&l.cfg.config)
+	return syslog.Priority((c & maskStackLvl) >> stackshift), c&maskDoStack != 0
+}
+
 // DoingTime returns whether the Logger is currently timestamping all events on
 // creation
 func (l *Logger) DoingTime() bool {
@@ -371,7 +411,8 @@ func (lc *lconfig) doingCode() bool {
 	return c&maskDoCode != 0
 }
 
-func (lc *lconfig) doing() (time, code bool) {
+func (lc *lconfig) doing(level syslog.Priority) (time, code, stack bool) {
 	c := atomic.LoadUint32(&lc.config)
-	return (c&maskDoTime != 0), (c&maskDoCode != 0)
+	stack = c&maskDoStack != 0 && uint32(level) <= (c&maskStackLvl)>>stackshift
+	return (c&maskDoTime != 0), (c&maskDoCode != 0), stack
 }
@@ -2,6 +2,9 @@ package log
 
 import (
 	"github.com/One-com/gone/log/syslog"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // Handler is the interface needed to be a part of the Handler chain.
@@ -66,3 +69,98 @@ func MultiHandler(hs ...Handler) Handler {
 		return maybeErr
 	})
 }
+
+//---
+
+type rateKey struct {
+	lvl syslog.Priority
+	msg string
+}
+
+type rateBucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int
+	name       string // logger name of the last suppressed event
+	timer      *time.Timer
+}
+
+type rateLimitHandler struct {
+	rate   float64
+	burst  float64
+	window time.Duration
+	h      Handler
+
+	mu      sync.Mutex
+	buckets map[rateKey]*rateBucket
+}
+
+// RateLimitHandler passes at most rate events per second (with bursts of up to burst events)
+// with the same level and message to h. Events exceeding the limit are discarded.
+// At the end of each window during which events were discarded, a summary event
+// "message repeated N times: <message>" is passed to h.
+// Messages are compared before KV data is added, so log the same message with different
+// KV data to have it limited as one.
+func RateLimitHandler(rate float64, burst int, window time.Duration, h Handler) Handler {
+	return &rateLimitHandler{
+		rate:    rate,
+		burst:   float64(burst),
+		window:  window,
+		h:       h,
+		buckets: make(map[rateKey]*rateBucket),
+	}
+}
+
+func (r *rateLimitHandler) Log(e Event) error {
+	key := rateKey{e.Lvl, e.Msg}
+	now := time.Now()
+
+	r.mu.Lock()
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &rateBucket{tokens: r.burst, last: now}
+		r.buckets[key] = b
+		b.timer = time.AfterFunc(r.window, func() { r.endWindow(key) })
+	}
+	r.refill(b, now)
+	if b.tokens < 1 {
+		b.suppressed++
+		b.name = e.Name
+		r.mu.Unlock()
+		return nil
+	}
+	b.tokens--
+	r.mu.Unlock()
+
+	return r.h.Log(e)
+}
+
+// refill must be called with the lock held
+func (r *rateLimitHandler) refill(b *rateBucket, now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * r.rate
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+	b.last = now
+}
+
+// Summarize discarded events and forget buckets which have been idle for a window.
+func (r *rateLimitHandler) endWindow(key rateKey) {
+	r.mu.Lock()
+	b := r.buckets[key]
+	n, name := b.suppressed, b.name
+	b.suppressed = 0
+	r.refill(b, time.Now())
+	if n == 0 && b.tokens >= r.burst {
+		delete(r.buckets, key)
+	} else {
+		b.timer.Reset(r.window)
+	}
+	r.mu.Unlock()
+
+	if n > 0 {
+		e := getPoolEvent(key.lvl, name, "message repeated "+strconv.Itoa(n)+" times: "+key.msg)
+		r.h.Log(Event{e})
+		freePoolEvent(e)
+	}
+}
@@ -0,0 +1,57 @@
+package log_test
+
+import (
+	"bytes"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A Handler formatting to a buffer which can be read while logging happens
+type syncBufHandler struct {
+	mu  sync.Mutex
+	b   bytes.Buffer
+	out log.Handler
+}
+
+func newSyncBufHandler() *syncBufHandler {
+	h := &syncBufHandler{}
+	h.out = log.NewStdFormatter(&h.b, "", 0)
+	return h
+}
+
+func (h *syncBufHandler) Log(e log.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.out.Log(e)
+}
+
+func (h *syncBufHandler) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.b.String()
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	out := newSyncBufHandler()
+	l := log.NewLogger(syslog.LOG_DEBUG, log.RateLimitHandler(1, 2, 100*time.Millisecond, out))
+
+	for i := 0; i < 10; i++ {
+		l.ERROR("backend down", "try", i)
+	}
+	l.WARN("backend down")
+	l.ERROR("other")
+
+	expected := "backend down try=0\nbackend down try=1\nbackend down\nother\n"
+	if got := out.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	expected += "message repeated 8 times: backend down\n"
+	if got := out.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,31 @@
+// Package httplog gives HTTP requests a request scoped Logger carried by the request context.
+//
+// Use it with the accesslog.RequestContext option from github.com/One-com/gone/http/handlers/accesslog:
+//
+//	accesslog.NewDynamicLogHandler(h, nil, accesslog.RequestContext(httplog.RequestContext(l)))
+//
+// or wrap a handler with Middleware. Request handlers then log with log.FromContext(req.Context()).
+package httplog
+
+import (
+	"context"
+	"github.com/One-com/gone/log"
+	"net/http"
+)
+
+// RequestContext returns a function giving an HTTP request a context carrying a child of l
+// with the request method and path as KV data.
+func RequestContext(l *log.Logger) func(w http.ResponseWriter, req *http.Request) context.Context {
+	return func(w http.ResponseWriter, req *http.Request) context.Context {
+		return log.NewContext(req.Context(), l.With("method", req.Method, "path", req.URL.Path))
+	}
+}
+
+// Middleware returns an http.Handler serving requests with h, giving each request a scoped Logger
+// like RequestContext.
+func Middleware(l *log.Logger, h http.Handler) http.Handler {
+	rc := RequestContext(l)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(w, req.WithContext(rc(w, req)))
+	})
+}
@@ -0,0 +1,31 @@
+package httplog_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/httplog"
+	"github.com/One-com/gone/log/syslog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type reqIDKey struct{}
+
+func TestMiddleware(t *testing.T) {
+	log.RegisterContextKey("req", reqIDKey{})
+	defer log.UnregisterContextKey(reqIDKey{})
+
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_INFO, log.NewStdFormatter(&b, "", 0))
+	h := httplog.Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), reqIDKey{}, 7)
+		log.FromContext(ctx).INFO("serving")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/path", nil))
+
+	if expected := "serving req=7 method=GET path=/path\n"; b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+}
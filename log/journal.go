@@ -0,0 +1,217 @@
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// DefaultJournalSocket is where systemd-journald listens for native protocol datagrams.
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// journalhandler is a Handler sending events to systemd-journald using
+// the native journal protocol.
+type journalhandler struct {
+	path       string
+	identifier string // SYSLOG_IDENTIFIER for unnamed loggers
+
+	conn *journalConn // shared among clones
+}
+
+type journalConn struct {
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// NewJournalHandler creates a Handler writing Events directly to the systemd journal.
+// Events are sent with PRIORITY, MESSAGE, CODE_FILE/CODE_LINE (if the Logger does code info)
+// and SYSLOG_IDENTIFIER set to the Logger name (or the program name for unnamed Loggers).
+// KV data is sent as journal fields with the key uppercased. Keys naming one of the fields
+// set by the Handler (like "message" or "priority") are prefixed with KV_.
+// Entries too big for a datagram are passed to journald in a memfd.
+// The socket is connected on first use. If journald can not be reached, Log returns an error,
+// letting the Logger try its parents.
+func NewJournalHandler(options ...HandlerOption) *journalhandler {
+	h := &journalhandler{
+		path:       DefaultJournalSocket,
+		identifier: filepath.Base(os.Args[0]),
+		conn:       &journalConn{},
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// Clone returns a clone of the current handler for tweaking and swapping in.
+// The clone shares the connection to the journal
+func (h *journalhandler) Clone(options ...HandlerOption) CloneableHandler {
+	new := &journalhandler{}
+	*new = *h
+	for _, option := range options {
+		option(new)
+	}
+	if new.path != h.path {
+		new.conn = &journalConn{}
+	}
+	return new
+}
+
+// JournalSocketOpt is a journal Handler option to set the path of the journald socket.
+func JournalSocketOpt(path string) HandlerOption {
+	return func(c CloneableHandler) {
+		if h, ok := c.(*journalhandler); ok {
+			h.path = path
+		}
+	}
+}
+
+// Log implements the Handler interface by sending the event to journald.
+func (h *journalhandler) Log(e Event) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	b := buf.tmp[:0]
+	b = appendJournalField(b, "PRIORITY", strconv.Itoa(int(e.Lvl&0x07)))
+	b = appendJournalField(b, "MESSAGE", e.Msg)
+	if e.fok {
+		file, line := e.FileInfo()
+		b = appendJournalField(b, "CODE_FILE", file)
+		b = appendJournalField(b, "CODE_LINE", strconv.Itoa(line))
+	}
+	if e.Name != "" {
+		b = appendJournalField(b, "SYSLOG_IDENTIFIER", e.Name)
+	} else if h.identifier != "" {
+		b = appendJournalField(b, "SYSLOG_IDENTIFIER", h.identifier)
+	}
+	data := withErrorCauses(flatKeyvals(e.Data))
+	for i := 0; i+1 < len(data); i += 2 {
+		name := journalFieldName(data[i])
+		if name == "" {
+			continue
+		}
+		if journalReserved[name] {
+			name = "KV_" + name
+		}
+		b = appendJournalField(b, name, valueString(data[i+1]))
+	}
+
+	return h.conn.send(h.path, b)
+}
+
+// Values without newlines are written as NAME=value, others as NAME\n<64bit LE size>value
+func appendJournalField(b []byte, name, value string) []byte {
+	b = append(b, name...)
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\n' {
+			var size [8]byte
+			binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+			b = append(b, '\n')
+			b = append(b, size[:]...)
+			b = append(b, value...)
+			return append(b, '\n')
+		}
+	}
+	b = append(b, '=')
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// The fields set by the Handler, which KV data must not override
+var journalReserved = map[string]bool{
+	"PRIORITY":          true,
+	"MESSAGE":           true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"SYSLOG_IDENTIFIER": true,
+}
+
+// Journal field names are uppercase ASCII letters, digits and '_', max 64 chars,
+// not starting with a digit or '_' (which is reserved for trusted fields).
+func journalFieldName(k interface{}) string {
+	var key string
+	switch x := k.(type) {
+	case string:
+		key = x
+	case fmt.Stringer:
+		key = safeString(x)
+	default:
+		key = fmt.Sprint(x)
+	}
+	name := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(name) < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9':
+			if len(name) == 0 {
+				continue
+			}
+		default:
+			c = '_'
+		}
+		if c == '_' && len(name) == 0 {
+			continue
+		}
+		name = append(name, c)
+	}
+	return string(name)
+}
+
+func (j *journalConn) send(path string, msg []byte) (err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for try := 0; try < 2; try++ {
+		if j.conn == nil {
+			var conn *net.UnixConn
+			conn, err = net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+			if err != nil {
+				return
+			}
+			j.conn = conn
+		}
+		_, err = j.conn.Write(msg)
+		if err == nil {
+			return
+		}
+		if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+			return j.sendFd(msg)
+		}
+		// Maybe journald was restarted. Reconnect and try again.
+		j.conn.Close()
+		j.conn = nil
+	}
+	return
+}
+
+// Pass an oversized entry to journald as a file descriptor.
+func (j *journalConn) sendFd(msg []byte) error {
+	f, err := journalFile(msg)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rights := syscall.UnixRights(int(f.Fd()))
+	// net.UnixConn refuses WriteMsgUnix on connected datagram sockets.
+	raw, err := j.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	cerr := raw.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// memfd_create(2) is not in the syscall package for all architectures.
+var sysMemfdCreate = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips":     4354,
+	"mipsle":   4354,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+const (
+	mfdCloexec      = 0x0001
+	mfdAllowSealing = 0x0002
+
+	fAddSeals   = 1033
+	fSealSeal   = 0x0001
+	fSealShrink = 0x0002
+	fSealGrow   = 0x0004
+	fSealWrite  = 0x0008
+)
+
+// journalFile returns a sealed memfd containing msg - or if memfd's are not supported
+// an unlinked file in /dev/shm, which journald will copy.
+func journalFile(msg []byte) (f *os.File, err error) {
+	if trap, ok := sysMemfdCreate[runtime.GOARCH]; ok {
+		name := []byte("journal-entry\x00")
+		fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(&name[0])), mfdCloexec|mfdAllowSealing, 0)
+		if errno == 0 {
+			f = os.NewFile(fd, "memfd:journal-entry")
+			if _, err = f.Write(msg); err != nil {
+				f.Close()
+				return nil, err
+			}
+			_, _, errno = syscall.Syscall(syscall.SYS_FCNTL, fd, fAddSeals, fSealSeal|fSealShrink|fSealGrow|fSealWrite)
+			if errno != 0 {
+				f.Close()
+				return nil, errno
+			}
+			return f, nil
+		}
+	}
+
+	f, err = ioutil.TempFile("/dev/shm", "journal-entry")
+	if err != nil {
+		return
+	}
+	os.Remove(f.Name())
+	if _, err = f.Write(msg); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return
+}
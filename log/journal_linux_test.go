@@ -0,0 +1,169 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// parse a native journal protocol entry into a map
+func parseJournalEntry(t *testing.T, b []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if i < 0 {
+			t.Fatalf("Bad journal entry: %q", b)
+		}
+		name := string(b[:i])
+		if b[i] == '=' {
+			j := bytes.IndexByte(b, '\n')
+			fields[name] = string(b[i+1 : j])
+			b = b[j+1:]
+		} else {
+			size := binary.LittleEndian.Uint64(b[i+1 : i+9])
+			fields[name] = string(b[i+9 : i+9+int(size)])
+			b = b[i+9+int(size)+1:]
+		}
+	}
+	return fields
+}
+
+func fakeJournal(t *testing.T) (*net.UnixConn, string, func()) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, path, func() {
+		conn.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestJournalHandler(t *testing.T) {
+	journal, path, cleanup := fakeJournal(t)
+	defer cleanup()
+
+	h := log.NewJournalHandler(log.JournalSocketOpt(path))
+	l := log.GetLogger("journal/test")
+	l.SetHandler(h)
+	l.DoCodeInfo(true)
+
+	l.ERROR("hello", "user-id", 42, "multi", "line1\nline2", "message", "kv", "Priority", 7,
+		"a key longer than thirty-two characters", 1)
+
+	buf := make([]byte, 4096)
+	n, err := journal.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := bytes.Count(buf[:n], []byte("\nMESSAGE=")); c != 1 {
+		t.Errorf("Expected one MESSAGE field, got %d", c)
+	}
+	fields := parseJournalEntry(t, buf[:n])
+
+	expected := map[string]string{
+		"PRIORITY":          "3",
+		"MESSAGE":           "hello",
+		"SYSLOG_IDENTIFIER": "journal/test",
+		"USER_ID":           "42",
+		"MULTI":             "line1\nline2",
+		"KV_MESSAGE":        "kv",
+		"KV_PRIORITY":       "7",
+		"A_KEY_LONGER_THAN_THIRTY_TWO_CHARACTERS": "1",
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("Field %s: expected %q, got %q", k, v, fields[k])
+		}
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "journal_linux_test.go") || fields["CODE_LINE"] == "" {
+		t.Errorf("Missing code info: %v", fields)
+	}
+
+	l.ERROR("failed", "err", testError())
+	n, err = journal.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields = parseJournalEntry(t, buf[:n])
+	expected = map[string]string{
+		"ERR":         "outer: joined",
+		"ERR_CAUSE_0": "joined",
+		"ERR_CAUSE_1": "middle: base",
+		"ERR_CAUSE_2": "base",
+		"ERR_CAUSE_3": "other",
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("Field %s: expected %q, got %q", k, v, fields[k])
+		}
+	}
+}
+
+func TestJournalHandlerOversized(t *testing.T) {
+	journal, path, cleanup := fakeJournal(t)
+	defer cleanup()
+
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewJournalHandler(log.JournalSocketOpt(path)))
+
+	big := strings.Repeat("x", 4*1024*1024)
+	if err := l.Log(syslog.LOG_INFO, big); err != nil {
+		t.Fatal(err)
+	}
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := journal.ReadMsgUnix(nil, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("Expected a file descriptor: %v", err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("Expected a file descriptor: %v", err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "entry")
+	defer f.Close()
+	f.Seek(0, 0)
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parseJournalEntry(t, content)
+	if fields["MESSAGE"] != big {
+		t.Errorf("Oversized message not passed in file")
+	}
+}
+
+func TestJournalHandlerRedacted(t *testing.T) {
+	journal, path, cleanup := fakeJournal(t)
+	defer cleanup()
+
+	l := log.NewLogger(syslog.LOG_DEBUG, log.RedactHandler(log.NewJournalHandler(log.JournalSocketOpt(path)), log.RedactKeys("pw")))
+	l.INFO("login", "user", log.KV{"name": "joe", "pw": "x"})
+
+	buf := make([]byte, 4096)
+	n, err := journal.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parseJournalEntry(t, buf[:n])
+	if fields["USER_NAME"] != "joe" || fields["USER_PW"] != "[REDACTED]" {
+		t.Errorf("Unexpected fields: %v", fields)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package log
+
+import (
+	"errors"
+	"os"
+)
+
+// journalFile is not supported without memfd's. There's no journald anyway.
+func journalFile(msg []byte) (*os.File, error) {
+	return nil, errors.New("Oversized journal entries not supported")
+}
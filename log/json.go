@@ -28,19 +28,27 @@ func (f *jsonformatter) Clone(options ...HandlerOption) CloneableHandler {
 	return new
 }
 
-// KeyNamesOpt is a JSON Formatter Option to set flags
+// KeyNamesOpt is a JSON and logfmt Formatter Option to set the keys of the event fields
 func KeyNamesOpt(keys *EventKeyNames) HandlerOption {
 	return func(c CloneableHandler) {
-		if h, ok := c.(*jsonformatter); ok {
+		switch h := c.(type) {
+		case *jsonformatter:
+			h.keynames = keys
+		case *logfmtformatter:
 			h.keynames = keys
 		}
 	}
 }
 
-// TimeFormatOpt is a JSON Formatter Option to set timestamp formatting
+// TimeFormatOpt is a JSON, logfmt and template Formatter Option to set timestamp formatting
 func TimeFormatOpt(layout string) HandlerOption {
 	return func(c CloneableHandler) {
-		if h, ok := c.(*jsonformatter); ok {
+		switch h := c.(type) {
+		case *jsonformatter:
+			h.timelayout = layout
+		case *logfmtformatter:
+			h.timelayout = layout
+		case *templateformatter:
 			h.timelayout = layout
 		}
 	}
@@ -59,6 +67,10 @@ func NewJSONFormatter(w io.Writer, options ...HandlerOption) *jsonformatter {
 
 // Log implements the Handler interface for the JSON formatter.
 func (f *jsonformatter) Log(e Event) error {
+	if ok, err := f.logFast(e); ok {
+		return err
+	}
+
 	x := len(e.Data)
 	n := x/2 + 3
 	m := make(map[string]interface{}, n)
@@ -68,6 +80,17 @@ func (f *jsonformatter) Log(e Event) error {
 		m[f.keynames.Name] = e.Name
 	}
 	m[f.keynames.Time] = e.Time().Format(f.timelayout)
+	if len(e.stack) > 0 && f.keynames.Stack != "" {
+		frames := e.StackTrace()
+		stack := make([]jsonFrame, len(frames))
+		for i, frame := range frames {
+			stack[i] = jsonFrame{Func: frame.Function, File: frame.File, Line: frame.Line}
+		}
+		m[f.keynames.Stack] = stack
+	}
+	for _, fl := range e.fields[:e.ctxfields] {
+		merge(m, fl.Key, fl.Value())
+	}
 	for i := 0; i < x; i += 2 {
 		k := e.Data[i]
 		var v interface{} = errors.New("MISSING")
@@ -76,6 +99,9 @@ func (f *jsonformatter) Log(e Event) error {
 		}
 		merge(m, k, v)
 	}
+	for _, fl := range e.fields[e.ctxfields:] {
+		merge(m, fl.Key, fl.Value())
+	}
 	return json.NewEncoder(f.out).Encode(m)
 }
 
@@ -109,6 +135,8 @@ func safeString(str fmt.Stringer) (s string) {
 	return
 }
 
+// safeError renders an error as its message. Errors wrapping other errors are rendered
+// as {"error": message, "causes": [cause, ...]} with the causes rendered the same way.
 func safeError(err error) (s interface{}) {
 	defer func() {
 		if panicVal := recover(); panicVal != nil {
@@ -119,6 +147,23 @@ func safeError(err error) (s interface{}) {
 			}
 		}
 	}()
-	s = err.Error()
+	msg := err.Error()
+	causes := unwrapErrors(err)
+	if len(causes) == 0 {
+		s = msg
+		return
+	}
+	rendered := make([]interface{}, len(causes))
+	for i, cause := range causes {
+		rendered[i] = safeError(cause)
+	}
+	s = map[string]interface{}{"error": msg, "causes": rendered}
 	return
 }
+
+// JSON rendering of stack frames
+type jsonFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
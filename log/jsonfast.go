@@ -0,0 +1,268 @@
+package log
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Allocation free JSON encoding of events with only simple values.
+// The output is the same as encoding/json gives for the map made by the JSON formatter.
+
+// Where the value of a JSON member comes from
+const (
+	jsonLvl = iota
+	jsonMsg
+	jsonName
+	jsonTime
+	jsonData  // e.Data[idx]
+	jsonField // e.fields[idx]
+)
+
+type jsonMember struct {
+	key  string
+	kind int
+	idx  int
+}
+
+type jsonState struct {
+	buf     []byte
+	tmp     []byte
+	members []jsonMember
+}
+
+var jsonPool = sync.Pool{New: func() interface{} { return new(jsonState) }}
+
+func (f *jsonformatter) encodesFields() {}
+
+// logFast writes the event if all its values are simple enough. Returns false if not.
+func (f *jsonformatter) logFast(e Event) (bool, error) {
+	if len(e.stack) > 0 || len(e.Data)%2 != 0 {
+		return false, nil
+	}
+	for i := 0; i < len(e.Data); i += 2 {
+		if _, ok := e.Data[i].(string); !ok || !simpleJSONValue(e.Data[i+1]) {
+			return false, nil
+		}
+	}
+	for _, fl := range e.fields {
+		switch fl.typ {
+		case fieldFloat64:
+			if v := math.Float64frombits(fl.num); math.IsNaN(v) || math.IsInf(v, 0) {
+				return false, nil
+			}
+		case fieldError:
+			if err, ok := fl.any.(error); ok && len(unwrapErrors(err)) > 0 {
+				return false, nil
+			}
+		case fieldAny:
+			if !simpleJSONValue(fl.any) {
+				return false, nil
+			}
+		}
+	}
+
+	st := jsonPool.Get().(*jsonState)
+	members := append(st.members[:0],
+		jsonMember{key: f.keynames.Lvl, kind: jsonLvl},
+		jsonMember{key: f.keynames.Msg, kind: jsonMsg})
+	if f.keynames.Name != "" {
+		members = append(members, jsonMember{key: f.keynames.Name, kind: jsonName})
+	}
+	members = append(members, jsonMember{key: f.keynames.Time, kind: jsonTime})
+	// In KV order, so later values of the same key win
+	for i := 0; i < e.ctxfields; i++ {
+		members = append(members, jsonMember{key: e.fields[i].Key, kind: jsonField, idx: i})
+	}
+	for i := 0; i < len(e.Data); i += 2 {
+		members = append(members, jsonMember{key: e.Data[i].(string), kind: jsonData, idx: i + 1})
+	}
+	for i := e.ctxfields; i < len(e.fields); i++ {
+		members = append(members, jsonMember{key: e.fields[i].Key, kind: jsonField, idx: i})
+	}
+	st.members = members
+
+	// Sort by key. Insertion sort is stable and the number of members small.
+	for i := 1; i < len(members); i++ {
+		for j := i; j > 0 && members[j].key < members[j-1].key; j-- {
+			members[j], members[j-1] = members[j-1], members[j]
+		}
+	}
+
+	buf := append(st.buf[:0], '{')
+	first := true
+	for i, m := range members {
+		if i+1 < len(members) && members[i+1].key == m.key {
+			continue // like a map, the last value wins
+		}
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = appendJSONString(buf, m.key)
+		buf = append(buf, ':')
+		switch m.kind {
+		case jsonLvl:
+			buf = strconv.AppendInt(buf, int64(e.Lvl), 10)
+		case jsonMsg:
+			buf = appendJSONString(buf, e.Msg)
+		case jsonName:
+			buf = appendJSONString(buf, e.Name)
+		case jsonTime:
+			st.tmp = e.Time().AppendFormat(st.tmp[:0], f.timelayout)
+			buf = appendJSONBytes(buf, st.tmp)
+		case jsonData:
+			buf = appendJSONValue(buf, e.Data[m.idx])
+		case jsonField:
+			buf = appendJSONField(buf, &e.fields[m.idx], st)
+		}
+	}
+	buf = append(buf, '}', '\n')
+	st.buf = buf
+
+	_, err := f.out.Write(buf)
+	jsonPool.Put(st)
+	return true, err
+}
+
+func simpleJSONValue(v interface{}) bool {
+	switch x := v.(type) {
+	case nil, string, int, int64, uint64, bool, time.Duration:
+		return true
+	case float64:
+		return !math.IsNaN(x) && !math.IsInf(x, 0)
+	}
+	return false
+}
+
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	switch x := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, x)
+	case int:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(buf, x, 10)
+	case time.Duration:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case uint64:
+		return strconv.AppendUint(buf, x, 10)
+	case bool:
+		return strconv.AppendBool(buf, x)
+	case float64:
+		return appendJSONFloat(buf, x)
+	}
+	return buf
+}
+
+func appendJSONField(buf []byte, fl *Field, st *jsonState) []byte {
+	switch fl.typ {
+	case fieldString:
+		return appendJSONString(buf, fl.str)
+	case fieldInt, fieldInt64, fieldDuration:
+		return strconv.AppendInt(buf, int64(fl.num), 10)
+	case fieldUint64:
+		return strconv.AppendUint(buf, fl.num, 10)
+	case fieldFloat64:
+		return appendJSONFloat(buf, math.Float64frombits(fl.num))
+	case fieldBool:
+		return strconv.AppendBool(buf, fl.num != 0)
+	case fieldTime:
+		st.tmp = fl.time().AppendFormat(st.tmp[:0], time.RFC3339Nano)
+		return appendJSONBytes(buf, st.tmp)
+	case fieldError:
+		if fl.any == nil {
+			return append(buf, "null"...)
+		}
+		return appendJSONString(buf, errorMessage(fl.any.(error)))
+	}
+	return appendJSONValue(buf, fl.any)
+}
+
+// Like encoding/json
+func appendJSONFloat(buf []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString quotes s like encoding/json does with HTML escaping.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// appendJSONBytes quotes b as a string without converting it (and allocating)
+func appendJSONBytes(buf []byte, b []byte) []byte {
+	for _, c := range b {
+		if c < ' ' || c >= utf8.RuneSelf || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			return appendJSONString(buf, string(b))
+		}
+	}
+	buf = append(buf, '"')
+	buf = append(buf, b...)
+	return append(buf, '"')
+}
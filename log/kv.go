@@ -26,6 +26,9 @@ func normalize(kv []interface{}) []interface{} {
 		return nil
 	}
 
+	// Fields given in place of key/value pairs
+	kv = expandFields(kv)
+
 	// if the caller passed a KV object or a Logable, then expand it
 	// and insist that the rest of the arguments are also Logables
 	var expkv []interface{}
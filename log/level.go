@@ -0,0 +1,40 @@
+package log
+
+import (
+	"errors"
+	"github.com/One-com/gone/log/syslog"
+	"strings"
+)
+
+var levelNames = [8]string{"emerg", "alert", "crit", "error", "warning", "notice", "info", "debug"}
+
+var levelAliases = map[string]syslog.Priority{
+	"emergency": syslog.LOG_EMERG,
+	"panic":     syslog.LOG_EMERG,
+	"critical":  syslog.LOG_CRIT,
+	"err":       syslog.LOG_ERR,
+	"warn":      syslog.LOG_WARN,
+}
+
+// ParseLevel parses a log level name (like "info", "warn", "error", case insensitive)
+// or a numeric level "0" to "7".
+func ParseLevel(s string) (syslog.Priority, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) == 1 && s[0] >= '0' && s[0] <= '7' {
+		return syslog.Priority(s[0] - '0'), nil
+	}
+	for i, name := range levelNames {
+		if s == name {
+			return syslog.Priority(i), nil
+		}
+	}
+	if lvl, ok := levelAliases[s]; ok {
+		return lvl, nil
+	}
+	return 0, errors.New("Unknown log level: " + s)
+}
+
+// LevelName returns the name of a log level as understood by ParseLevel.
+func LevelName(level syslog.Priority) string {
+	return levelNames[level&0x07]
+}
@@ -0,0 +1,148 @@
+package log
+
+import (
+	"github.com/go-logfmt/logfmt"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// logfmtformatter writes events as logfmt records with the event fields as keys.
+type logfmtformatter struct {
+	flag       int // which event fields to include
+	out        io.Writer
+	timelayout string
+	keynames   *EventKeyNames
+}
+
+var logfmtKeyNames = &EventKeyNames{
+	Lvl:  "level",
+	Name: "logger",
+	Time: "ts",
+	Msg:  "msg",
+	File: "file",
+	Line: "line",
+}
+
+// NewLogfmtFormatter creates a formatting Handler writing events as logfmt records like:
+//
+//	level=info ts=2009-11-10T23:00:00Z logger=my/lib msg="Hello World" k=v
+//
+// Flags (see FlagsOpt) select the event fields: Llevel, Ldate/Ltime (the timestamp), Lname, Lpid and
+// Lshortfile/Llongfile, with LUTC and Lcolor supported.
+// The default flags are Llevel|LstdFlags|Lname.
+// The keys are set by KeyNamesOpt, leaving a key empty omits the field. The message is always included,
+// with the key "msg" if none is set.
+// The timestamp is formatted with the layout set by TimeFormatOpt, default RFC3339.
+func NewLogfmtFormatter(w io.Writer, options ...HandlerOption) *logfmtformatter {
+	f := &logfmtformatter{
+		flag:       Llevel | LstdFlags | Lname,
+		out:        w,
+		timelayout: time.RFC3339,
+		keynames:   logfmtKeyNames,
+	}
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+// Clone returns a clone of the current handler for tweaking and swapping in
+func (f *logfmtformatter) Clone(options ...HandlerOption) CloneableHandler {
+	new := &logfmtformatter{}
+	*new = *f
+	for _, option := range options {
+		option(new)
+	}
+	return new
+}
+
+// Flags returns the formatter flags
+func (f *logfmtformatter) Flags() int {
+	return f.flag
+}
+
+// Prefix is not supported by the logfmt formatter, but needed for StdFormatter
+func (f *logfmtformatter) Prefix() string {
+	return ""
+}
+
+// SetFlags creates a HandlerOption to set flags.
+func (f *logfmtformatter) SetFlags(flags int) HandlerOption {
+	return FlagsOpt(flags)
+}
+
+// SetOutput creates a HandlerOption to set the output writer
+func (f *logfmtformatter) SetOutput(w io.Writer) HandlerOption {
+	return OutputOpt(w)
+}
+
+// AutoColoring creates a HandlerOption coloring the level field, if the output is a terminal.
+func (f *logfmtformatter) AutoColoring() HandlerOption {
+	return func(c CloneableHandler) {
+		if o, ok := c.(*logfmtformatter); ok {
+			o.flag = colorFlag(o.flag, o.out)
+		}
+	}
+}
+
+// Log implements the Handler interface
+func (f *logfmtformatter) Log(e Event) error {
+	buf := getBuffer()
+	enc := logfmt.NewEncoder(&buf.Buffer)
+	keys := f.keynames
+
+	if f.flag&Llevel != 0 && keys.Lvl != "" {
+		if f.flag&Lcolor != 0 {
+			buf.WriteString("\x1b[" + levelColors[e.Lvl&0x07] + "m")
+			enc.EncodeKeyval(keys.Lvl, LevelName(e.Lvl))
+			buf.WriteString("\x1b[0m")
+		} else {
+			enc.EncodeKeyval(keys.Lvl, LevelName(e.Lvl))
+		}
+	}
+	if f.flag&(Ldate|Ltime|Lmicroseconds) != 0 && keys.Time != "" {
+		t := e.Time()
+		if f.flag&LUTC != 0 {
+			t = t.UTC()
+		}
+		enc.EncodeKeyval(keys.Time, t.Format(f.timelayout))
+	}
+	if f.flag&Lname != 0 && e.Name != "" && keys.Name != "" {
+		enc.EncodeKeyval(keys.Name, e.Name)
+	}
+	if f.flag&Lpid != 0 {
+		enc.EncodeKeyval("pid", pid)
+	}
+	if f.flag&(Lshortfile|Llongfile) != 0 && e.fok {
+		file, line := e.FileInfo()
+		if f.flag&Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		if keys.File != "" {
+			enc.EncodeKeyval(keys.File, file)
+		}
+		if keys.Line != "" {
+			enc.EncodeKeyval(keys.Line, line)
+		}
+	}
+	msgkey := keys.Msg
+	if msgkey == "" {
+		msgkey = logfmtKeyNames.Msg
+	}
+	enc.EncodeKeyval(msgkey, e.Msg)
+	encodeKeyvals(enc, e.Data...)
+	if causes := errorCauseKeyvals(e.Data); causes != nil {
+		encodeKeyvals(enc, causes...)
+	}
+	enc.EndRecord()
+
+	var err error
+	if l, ok := f.out.(EvWriter); ok {
+		_, err = l.EvWrite(e, buf.Bytes())
+	} else {
+		_, err = f.out.Write(buf.Bytes())
+	}
+	putBuffer(buf)
+	return err
+}
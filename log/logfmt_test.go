@@ -0,0 +1,46 @@
+package log_test
+
+import (
+	"bytes"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"regexp"
+	"testing"
+)
+
+func TestLogfmtFormatter(t *testing.T) {
+	var b bytes.Buffer
+	h := log.NewLogfmtFormatter(&b, log.TimeFormatOpt("2006"))
+	l := log.GetLogger("logfmt/test")
+	l.SetHandler(h)
+	l.SetLevel(syslog.LOG_DEBUG)
+
+	l.WARN("hello world", "k", 1)
+	if !regexp.MustCompile(`^level=warning ts=\d{4} logger=logfmt/test msg="hello world" k=1\n$`).MatchString(b.String()) {
+		t.Errorf("Unexpected output: %q", b.String())
+	}
+
+	b.Reset()
+	l.DoCodeInfo(true)
+	l.ApplyHandlerOptions(log.FlagsOpt(log.Llevel|log.Lshortfile),
+		log.KeyNamesOpt(&log.EventKeyNames{Lvl: "lvl", Msg: "message", File: "src"}))
+	l.INFO("x")
+	if !regexp.MustCompile(`^lvl=info src=logfmt_test.go message=x\n$`).MatchString(b.String()) {
+		t.Errorf("Unexpected output: %q", b.String())
+	}
+
+	// An empty message key falls back to the default
+	b.Reset()
+	l.ApplyHandlerOptions(log.KeyNamesOpt(&log.EventKeyNames{Lvl: "lvl"}))
+	l.INFO("y")
+	if b.String() != "lvl=info msg=y\n" {
+		t.Errorf("Unexpected output: %q", b.String())
+	}
+
+	var o bytes.Buffer
+	l.SetOutput(&o)
+	l.INFO("moved")
+	if o.String() != "lvl=info msg=moved\n" {
+		t.Errorf("SetOutput not supported: %q", o.String())
+	}
+}
@@ -0,0 +1,236 @@
+// Package logtest provides a capturing Handler for asserting what code logs in unit tests.
+//
+//	rec, detach := logtest.Attach("my/lib")
+//	defer detach()
+//	... code logging to "my/lib" ...
+//	rec.AssertLogged(t, syslog.LOG_ERROR, "connection failed", "host", "db1")
+package logtest
+
+import (
+	"fmt"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Entry is a copy of a captured log event.
+type Entry struct {
+	Level syslog.Priority
+	Name  string
+	Msg   string
+	Data  log.KeyValues // Lazy values are evaluated, KV maps copied.
+	File  string        // Only set if the Logger does code info
+	Line  int
+	Time  time.Time
+}
+
+// Value returns the value of the first KV pair with key.
+func (e Entry) Value(key string) (interface{}, bool) {
+	for i := 0; i+1 < len(e.Data); i += 2 {
+		if k, ok := e.Data[i].(string); ok && k == key {
+			return e.Data[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// Matches tells whether the entry has the level, message and all the key/value pairs in kv.
+func (e Entry) Matches(level syslog.Priority, msg string, kv ...interface{}) bool {
+	if e.Level != level || e.Msg != msg {
+		return false
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			return false
+		}
+		v, ok := e.Value(key)
+		if !ok || !reflect.DeepEqual(v, kv[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s [%s] %q", log.LevelName(e.Level), e.Name, e.Msg)
+	for i := 0; i+1 < len(e.Data); i += 2 {
+		fmt.Fprintf(&b, " %v=%#v", e.Data[i], e.Data[i+1])
+	}
+	return b.String()
+}
+
+// Recorder is a Handler capturing events.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+	notify  chan struct{} // closed when an entry is added
+}
+
+// NewRecorder creates an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{notify: make(chan struct{})}
+}
+
+// Log implements log.Handler
+func (r *Recorder) Log(e log.Event) error {
+	entry := Entry{
+		Level: e.Lvl,
+		Name:  e.Name,
+		Msg:   e.Msg,
+		Time:  e.Time(),
+		Data:  copyKeyvals(e.Data),
+	}
+	entry.File, entry.Line = e.FileInfo()
+
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	close(r.notify)
+	r.notify = make(chan struct{})
+	r.mu.Unlock()
+	return nil
+}
+
+// Deep copy KV data, so it can't change after being logged.
+func copyKeyvals(kv []interface{}) log.KeyValues {
+	if kv == nil {
+		return nil
+	}
+	c := make(log.KeyValues, len(kv))
+	for i, v := range kv {
+		c[i] = copyValue(v)
+	}
+	return c
+}
+
+func copyValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case log.Lazy:
+		return copyValue(x())
+	case log.KV:
+		c := make(log.KV, len(x))
+		for k, v := range x {
+			c[k] = copyValue(v)
+		}
+		return c
+	case log.KeyValues:
+		return copyKeyvals(x)
+	case []interface{}:
+		return []interface{}(copyKeyvals(x))
+	}
+	return v
+}
+
+// Entries returns a copy of the captured entries.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Len returns the number of captured entries.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// Reset forgets all captured entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+// Find returns the first entry matching level, message and key/value pairs (see Entry.Matches).
+func (r *Recorder) Find(level syslog.Priority, msg string, kv ...interface{}) (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(level, msg, kv)
+}
+
+func (r *Recorder) find(level syslog.Priority, msg string, kv []interface{}) (Entry, bool) {
+	for _, e := range r.entries {
+		if e.Matches(level, msg, kv...) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Logged tells whether a matching entry was captured.
+func (r *Recorder) Logged(level syslog.Priority, msg string, kv ...interface{}) bool {
+	_, ok := r.Find(level, msg, kv...)
+	return ok
+}
+
+// WaitFor waits up to timeout for a matching entry to be captured - for testing code logging
+// from other go-routines.
+func (r *Recorder) WaitFor(timeout time.Duration, level syslog.Priority, msg string, kv ...interface{}) (Entry, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		r.mu.Lock()
+		e, ok := r.find(level, msg, kv)
+		notify := r.notify
+		r.mu.Unlock()
+		if ok {
+			return e, true
+		}
+		select {
+		case <-notify:
+		case <-timer.C:
+			return Entry{}, false
+		}
+	}
+}
+
+// AssertLogged fails the test if no matching entry was captured.
+func (r *Recorder) AssertLogged(t testing.TB, level syslog.Priority, msg string, kv ...interface{}) {
+	t.Helper()
+	if !r.Logged(level, msg, kv...) {
+		t.Errorf("No log entry matching %s %q %v. Got:\n%s", log.LevelName(level), msg, kv, r.dump())
+	}
+}
+
+// AssertNotLogged fails the test if a matching entry was captured.
+func (r *Recorder) AssertNotLogged(t testing.TB, level syslog.Priority, msg string, kv ...interface{}) {
+	t.Helper()
+	if e, ok := r.Find(level, msg, kv...); ok {
+		t.Errorf("Unexpected log entry: %s", e)
+	}
+}
+
+func (r *Recorder) dump() string {
+	var b strings.Builder
+	for _, e := range r.Entries() {
+		b.WriteString("\t" + e.String() + "\n")
+	}
+	return b.String()
+}
+
+// AttachTo makes the Recorder the Handler of l and sets l to log at all levels.
+// The returned function restores the Handler and level of l.
+func (r *Recorder) AttachTo(l *log.Logger) (detach func()) {
+	h, lvl := l.Handler(), l.Level()
+	l.SetHandler(r)
+	l.SetLevel(syslog.LOG_DEBUG)
+	return func() {
+		l.SetHandler(h)
+		l.SetLevel(lvl)
+	}
+}
+
+// Attach creates a Recorder capturing events of the named Logger in the Logger
+// hierarchy - including events from children Loggers without their own Handler.
+// Only the level of the named Logger is changed, children Loggers keep their level.
+// Call detach to restore the Logger.
+func Attach(name string) (r *Recorder, detach func()) {
+	r = NewRecorder()
+	return r, r.AttachTo(log.GetLogger(name))
+}
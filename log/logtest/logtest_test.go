@@ -0,0 +1,42 @@
+package logtest_test
+
+import (
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/logtest"
+	"github.com/One-com/gone/log/syslog"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	rec, detach := logtest.Attach("logtest/app")
+	l := log.GetLogger("logtest/app")
+
+	m := log.KV{"user": "joe"}
+	l.WARN("login failed", "tries", 3, "who", m, "lazy", log.Lazy(func() interface{} { return "evaluated" }))
+	m["user"] = "changed"
+
+	rec.AssertLogged(t, syslog.LOG_WARN, "login failed", "tries", 3, "who", log.KV{"user": "joe"}, "lazy", "evaluated")
+	rec.AssertNotLogged(t, syslog.LOG_ERROR, "login failed")
+	if rec.Logged(syslog.LOG_WARN, "login failed", "tries", 4) {
+		t.Error("Matched wrong value")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		log.GetLogger("logtest/app/worker").NOTICE("done", "job", 1)
+	}()
+	e, ok := rec.WaitFor(time.Second, syslog.LOG_NOTICE, "done", "job", 1)
+	if !ok || e.Name != "logtest/app/worker" {
+		t.Errorf("Event from child Logger not captured: %v", e)
+	}
+	if _, ok := rec.WaitFor(10*time.Millisecond, syslog.LOG_INFO, "never"); ok {
+		t.Error("Found event never logged")
+	}
+
+	detach()
+	l.ERROR("after detach")
+	if rec.Len() != 2 || l.Handler() != nil {
+		t.Errorf("Recorder not detached: %v", rec.Entries())
+	}
+}
@@ -1,6 +1,8 @@
 package log
 
 import (
+	"errors"
+	"github.com/One-com/gone/log/syslog"
 	"strings"
 	"sync"
 )
@@ -32,7 +34,8 @@ func newPlaceholder(l *Logger) (p *placeholder) {
 type manager struct {
 	mu       sync.Mutex
 	root     *Logger
-	registry map[string]interface{} // contains either *Logger or *placeholder
+	registry map[string]interface{}     // contains either *Logger or *placeholder
+	levels   map[string]syslog.Priority // level rules by name prefix. "" is the default.
 }
 
 var man *manager
@@ -48,6 +51,22 @@ func GetLogger(name string) (l *Logger) {
 	return man.getLogger(name)
 }
 
+// LookupLogger returns the Logger with the given name - if it has been created by GetLogger().
+// The root (default) Logger has the name "".
+func LookupLogger(name string) (l *Logger, ok bool) {
+	return man.lookupLogger(name)
+}
+
+func (m *manager) lookupLogger(name string) (l *Logger, ok bool) {
+	if name == "" {
+		return m.root, true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok = m.registry[name].(*Logger)
+	return
+}
+
 func (m *manager) getLogger(name string) (l *Logger) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -55,6 +74,7 @@ func (m *manager) getLogger(name string) (l *Logger) {
 	if node, ok := m.registry[name]; ok {
 		if p, ok := node.(*placeholder); ok {
 			l = newLogger(name)
+			m.applyLevel(l)
 			m.registry[name] = l
 			m.fixupChildren(p, l)
 			m.fixupParents(l)
@@ -63,12 +83,120 @@ func (m *manager) getLogger(name string) (l *Logger) {
 		l = node.(*Logger) // must be a Logger.
 	} else {
 		l = newLogger(name)
+		m.applyLevel(l)
 		m.registry[name] = l
 		m.fixupParents(l)
 	}
 	return
 }
 
+// SetLevels sets log levels for the named Logger hierarchy from a spec like:
+//
+//	"info,http=debug,db/pool=warn"
+//
+// A level without a name sets the level of the root (default) Logger and all Loggers not matched
+// by other rules. A named rule sets the level of the Logger with that name and all Loggers below it,
+// with the longest matching name winning.
+// The rules replace any previous rules and apply also to Loggers created later by GetLogger().
+// Loggers not matched by any rule keep their level.
+func SetLevels(spec string) error {
+	levels := make(map[string]string)
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		var name string
+		if i := strings.LastIndexByte(rule, '='); i >= 0 {
+			name, rule = strings.TrimSpace(rule[:i]), rule[i+1:]
+			if name == "" {
+				return errors.New("Missing logger name in level spec")
+			}
+		}
+		levels[name] = rule
+	}
+	return SetLevelMap(levels)
+}
+
+// SetLevelMap is like SetLevels, but takes the rules as a map from Logger name
+// to level name (as understood by ParseLevel). The "" key is the default level.
+// If any level fails to parse, no levels are changed.
+func SetLevelMap(levels map[string]string) error {
+	rules := make(map[string]syslog.Priority, len(levels))
+	for name, level := range levels {
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		rules[name] = lvl
+	}
+	man.setLevels(rules)
+	return nil
+}
+
+// Levels returns the current level of all Loggers in the named Logger hierarchy.
+// The root (default) Logger has the name "".
+func Levels() map[string]syslog.Priority {
+	return man.getLevels()
+}
+
+func (m *manager) setLevels(rules map[string]syslog.Priority) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.levels = rules
+	if lvl, ok := rules[""]; ok {
+		setLevel(m.root, lvl)
+	}
+	for _, node := range m.registry {
+		if l, ok := node.(*Logger); ok {
+			m.applyLevel(l)
+		}
+	}
+}
+
+func (m *manager) getLevels() map[string]syslog.Priority {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	levels := map[string]syslog.Priority{"": m.root.Level()}
+	for name, node := range m.registry {
+		if l, ok := node.(*Logger); ok {
+			levels[name] = l.Level()
+		}
+	}
+	return levels
+}
+
+// Set the level of the Logger from the most specific matching rule - if any.
+// must be called under manager mutex lock
+func (m *manager) applyLevel(l *Logger) {
+	if m.levels == nil {
+		return
+	}
+	name := l.name
+	for {
+		if lvl, ok := m.levels[name]; ok {
+			setLevel(l, lvl)
+			return
+		}
+		if name == "" {
+			return
+		}
+		i := strings.LastIndexByte(name, '/')
+		if i < 0 {
+			i = 0
+		}
+		name = name[:i]
+	}
+}
+
+// SetLevel can lose a race with other config changes. Retry until it doesn't.
+func setLevel(l *Logger, level syslog.Priority) {
+	for !l.SetLevel(level) {
+	}
+}
+
 // Ensure that there are either loggers or placeholders all the way
 // from the specified logger to the root of the logger hierarchy.
 func (m *manager) fixupParents(l *Logger) {
@@ -0,0 +1,74 @@
+package log_test
+
+import (
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"testing"
+)
+
+func TestSetLevels(t *testing.T) {
+	rootLevel := log.Level()
+	defer func() {
+		log.SetLevelMap(nil)
+		log.SetLevel(rootLevel)
+	}()
+
+	before := log.GetLogger("lvl/http/client")
+	other := log.GetLogger("lvl/httpd")
+
+	err := log.SetLevels("notice, lvl/http=debug,lvl/db/pool=warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := log.GetLogger("lvl/db/pool/conn")
+
+	expected := map[string]syslog.Priority{
+		"":                 syslog.LOG_NOTICE,
+		"lvl/http/client":  syslog.LOG_DEBUG,
+		"lvl/httpd":        syslog.LOG_NOTICE,
+		"lvl/db/pool/conn": syslog.LOG_WARN,
+	}
+	levels := log.Levels()
+	for name, lvl := range expected {
+		if levels[name] != lvl {
+			t.Errorf("%q: expected level %d, got %d", name, lvl, levels[name])
+		}
+	}
+	if before.Level() != syslog.LOG_DEBUG || other.Level() != syslog.LOG_NOTICE || after.Level() != syslog.LOG_WARN {
+		t.Error("Levels not applied to Loggers")
+	}
+	if _, ok := levels["lvl/db"]; ok {
+		t.Error("Placeholder reported as Logger")
+	}
+
+	if err = log.SetLevels("info,lvl/http=loud"); err == nil {
+		t.Error("Bad level accepted")
+	}
+	if before.Level() != syslog.LOG_DEBUG {
+		t.Error("Levels changed by bad spec")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, s := range []string{"warn", "WARNING", "4", " Warn "} {
+		if lvl, err := log.ParseLevel(s); err != nil || lvl != syslog.LOG_WARN {
+			t.Errorf("%q: got %d, %v", s, lvl, err)
+		}
+	}
+	if log.LevelName(syslog.LOG_ERR) != "error" {
+		t.Error("Bad level name")
+	}
+}
+
+func TestLookupLogger(t *testing.T) {
+	l := log.GetLogger("lookup/a/b")
+	if got, ok := log.LookupLogger("lookup/a/b"); !ok || got != l {
+		t.Error("Existing Logger not found")
+	}
+	if _, ok := log.LookupLogger("lookup/a"); ok {
+		t.Error("Found Logger never created")
+	}
+	if got, ok := log.LookupLogger(""); !ok || got != log.Default() {
+		t.Error("Root Logger not found")
+	}
+}
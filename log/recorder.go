@@ -0,0 +1,121 @@
+package log
+
+import (
+	"github.com/One-com/gone/log/syslog"
+	"sync"
+)
+
+// FlightRecorder is a Handler keeping a history of the latest events in a ring buffer
+// to provide context when things go wrong.
+// All events are recorded in the ring. Events at or below (more severe than) the pass level
+// are also passed on to the downstream Handler immediately.
+// When an event at or below the trigger level arrives, the events in the ring not already
+// passed on are passed to the downstream Handler (oldest first) before the event itself,
+// and the ring is emptied.
+//
+// Remember the Logger must be set to generate events at the levels to be recorded.
+// Typically: Logger at LOG_DEBUG, pass at LOG_INFO and trigger at LOG_ERROR.
+type FlightRecorder struct {
+	h       Handler
+	pass    syslog.Priority
+	trigger syslog.Priority
+
+	mu   sync.Mutex
+	ring []recorded
+	next int // where to record the next event
+	n    int // number of events in the ring
+}
+
+type recorded struct {
+	e      *event
+	passed bool // already passed to the downstream Handler
+}
+
+// NewFlightRecorder creates a FlightRecorder keeping up to size events in the ring.
+func NewFlightRecorder(h Handler, size int, pass, trigger syslog.Priority) *FlightRecorder {
+	return &FlightRecorder{
+		h:       h,
+		pass:    pass,
+		trigger: trigger,
+		ring:    make([]recorded, size),
+	}
+}
+
+// Log implements Handler
+func (f *FlightRecorder) Log(e Event) error {
+	if len(f.ring) == 0 {
+		return f.h.Log(e)
+	}
+	trigger := e.Lvl <= f.trigger
+	pass := trigger || e.Lvl <= f.pass
+
+	var history []*event
+	f.mu.Lock()
+	if trigger {
+		history = f.events(false)
+		f.reset()
+	}
+	f.ring[f.next] = recorded{e: e.clone(), passed: pass}
+	f.next = (f.next + 1) % len(f.ring)
+	if f.n < len(f.ring) {
+		f.n++
+	}
+	f.mu.Unlock()
+
+	// Call the downstream Handler without the lock, so it may log itself.
+	logEvents(f.h, history)
+	if pass {
+		return f.h.Log(e)
+	}
+	return nil
+}
+
+// Dump passes the recorded events not already passed on to the downstream Handler
+// and empties the ring.
+func (f *FlightRecorder) Dump() error {
+	f.mu.Lock()
+	history := f.events(false)
+	f.reset()
+	f.mu.Unlock()
+	return logEvents(f.h, history)
+}
+
+// DumpTo passes all the recorded events to h, keeping them in the ring.
+// Use it to inspect the history - like from a control socket.
+func (f *FlightRecorder) DumpTo(h Handler) error {
+	f.mu.Lock()
+	history := f.events(true)
+	f.mu.Unlock()
+	return logEvents(h, history)
+}
+
+// events returns the recorded events, oldest first - optionally including the events passed on.
+// Must be called with the lock held.
+func (f *FlightRecorder) events(passed bool) (history []*event) {
+	size := len(f.ring)
+	for i := 0; i < f.n; i++ {
+		r := f.ring[(f.next-f.n+i+size)%size]
+		if passed || !r.passed {
+			history = append(history, r.e)
+		}
+	}
+	return
+}
+
+// logEvents passes events to h. Returns the last error from h.
+func logEvents(h Handler, events []*event) (err error) {
+	for _, e := range events {
+		if lerr := h.Log(Event{e}); lerr != nil {
+			err = lerr
+		}
+	}
+	return
+}
+
+// must be called with the lock held
+func (f *FlightRecorder) reset() {
+	for i := range f.ring {
+		f.ring[i] = recorded{}
+	}
+	f.next, f.n = 0, 0
+}
@@ -0,0 +1,73 @@
+package log_test
+
+import (
+	"bytes"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"testing"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	var b bytes.Buffer
+	fr := log.NewFlightRecorder(log.NewStdFormatter(&b, "", log.Llevel), 4, syslog.LOG_INFO, syslog.LOG_ERROR)
+	l := log.NewLogger(syslog.LOG_DEBUG, fr)
+
+	l.DEBUG("d1")
+	l.INFO("i1")
+	l.DEBUG("d2", "k", 1)
+	l.DEBUG("d3")
+	l.DEBUG("d4")
+
+	if b.String() != "<6>i1\n" {
+		t.Errorf("Unexpected output before trigger: %q", b.String())
+	}
+
+	var d bytes.Buffer
+	fr.DumpTo(log.NewStdFormatter(&d, "", 0))
+	if d.String() != "i1\nd2 k=1\nd3\nd4\n" {
+		t.Errorf("Unexpected dump: %q", d.String())
+	}
+
+	b.Reset()
+	l.ERROR("e1")
+	l.DEBUG("d5")
+	l.ERROR("e2")
+	if b.String() != "<7>d2 k=1\n<7>d3\n<7>d4\n<3>e1\n<7>d5\n<3>e2\n" {
+		t.Errorf("Unexpected output after trigger: %q", b.String())
+	}
+
+	b.Reset()
+	l.DEBUG("d6")
+	fr.Dump()
+	fr.Dump()
+	if b.String() != "<7>d6\n" {
+		t.Errorf("Unexpected output of Dump: %q", b.String())
+	}
+}
+
+// A Handler logging through the Logger it is handling events for
+type reentrantHandler struct {
+	l   *log.Logger
+	out bytes.Buffer
+}
+
+func (r *reentrantHandler) Log(e log.Event) error {
+	if e.Msg == "request" {
+		r.l.DEBUG("handling request")
+	}
+	r.out.WriteString(e.Msg + "\n")
+	return nil
+}
+
+func TestFlightRecorderReentrant(t *testing.T) {
+	r := &reentrantHandler{}
+	fr := log.NewFlightRecorder(r, 4, syslog.LOG_INFO, syslog.LOG_ERROR)
+	r.l = log.NewLogger(syslog.LOG_DEBUG, fr)
+
+	r.l.INFO("request")
+	r.l.ERROR("failed")
+
+	if got := r.out.String(); got != "request\nhandling request\nfailed\n" {
+		t.Errorf("Unexpected output: %q", got)
+	}
+}
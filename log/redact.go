@@ -0,0 +1,247 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redactable is the interface of values which know how to hide their sensitive
+// parts when logged through a RedactHandler.
+// Redact returns the value to log instead - which is redacted further if needed.
+type Redactable interface {
+	Redact() interface{}
+}
+
+// Value patterns for use with RedactValues
+var (
+	CardNumberPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	BearerTokenPattern = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._~+/-]+=*`)
+)
+
+// How deep nested values are redacted. Deeper values are replaced.
+const maxRedactDepth = 10
+
+// redactedKV is a redacted copy of a map or Logable value.
+type redactedKV KV
+
+// LogValues implements Logable
+func (kv redactedKV) LogValues() KeyValues {
+	return KV(kv).LogValues()
+}
+
+type redactor struct {
+	keys        []string // lower case
+	values      []*regexp.Regexp
+	replacement string
+}
+
+// RedactOption configures a RedactHandler
+type RedactOption func(*redactor)
+
+// RedactKeys redacts values of keys containing any of the patterns (case insensitive).
+func RedactKeys(patterns ...string) RedactOption {
+	return func(r *redactor) {
+		for _, p := range patterns {
+			r.keys = append(r.keys, strings.ToLower(p))
+		}
+	}
+}
+
+// RedactValues replaces anything matching the regular expressions in string values.
+// Lazy, error and fmt.Stringer values are turned into strings if they match.
+func RedactValues(patterns ...*regexp.Regexp) RedactOption {
+	return func(r *redactor) {
+		r.values = append(r.values, patterns...)
+	}
+}
+
+// RedactReplacement sets what redacted values are replaced with. Default is "[REDACTED]".
+func RedactReplacement(replacement string) RedactOption {
+	return func(r *redactor) {
+		r.replacement = replacement
+	}
+}
+
+// RedactHandler scrubs sensitive KV data before passing events to h.
+// Values are redacted by key name (see RedactKeys) and by content (see RedactValues).
+// Values implementing Redactable are replaced by their redacted version.
+// Maps with string keys (like KV and http.Header), Logable values and slices are scrubbed
+// recursively, up to a nesting depth of 10. Redacted maps and Logable values are passed on
+// as a map, which the formatters (except JSON) write as key.subkey=value pairs.
+// Events are copied if modified, so the original data is never changed.
+func RedactHandler(h Handler, options ...RedactOption) Handler {
+	r := &redactor{replacement: "[REDACTED]"}
+	for _, option := range options {
+		option(r)
+	}
+	return HandlerFunc(func(e Event) error {
+		var data []interface{}
+		for i := 0; i+1 < len(e.Data); i += 2 {
+			v, changed := r.redact(e.Data[i], e.Data[i+1], 0)
+			if !changed {
+				continue
+			}
+			if data == nil {
+				data = make([]interface{}, len(e.Data))
+				copy(data, e.Data)
+			}
+			data[i+1] = v
+		}
+		if data == nil {
+			return h.Log(e)
+		}
+		redacted := *e.event
+		redacted.Data = data
+		return h.Log(Event{&redacted})
+	})
+}
+
+func (r *redactor) sensitiveKey(k interface{}) bool {
+	if len(r.keys) == 0 {
+		return false
+	}
+	key, ok := k.(string)
+	if !ok {
+		key = fmt.Sprint(k)
+	}
+	key = strings.ToLower(key)
+	for _, p := range r.keys {
+		if strings.Contains(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) scrub(s string) (string, bool) {
+	changed := false
+	for _, re := range r.values {
+		if re.MatchString(s) {
+			s = re.ReplaceAllLiteralString(s, r.replacement)
+			changed = true
+		}
+	}
+	return s, changed
+}
+
+// redact returns the value to log for the key/value pair and whether it's changed.
+// depth is the nesting level of the value.
+func (r *redactor) redact(k, v interface{}, depth int) (interface{}, bool) {
+	if r.sensitiveKey(k) {
+		return r.replacement, true
+	}
+	if depth > maxRedactDepth {
+		return r.replacement, true
+	}
+
+	switch x := v.(type) {
+	case nil:
+		return v, false
+	case Redactable: // before fmt.Stringer and error, which could reveal the value
+		nv, _ := r.redact(k, x.Redact(), depth+1)
+		return nv, true
+	case string:
+		return r.scrub(x)
+	case Lazy:
+		if len(r.values) > 0 {
+			if s, changed := r.scrub(x.evaluate()); changed {
+				return s, true
+			}
+		}
+		return v, false
+	case KV:
+		return r.redactKeyvals(x.LogValues(), depth)
+	case error:
+		if len(r.values) > 0 {
+			if s, changed := r.scrub(errorMessage(x)); changed {
+				return s, true
+			}
+		}
+		return v, false
+	case fmt.Stringer:
+		if len(r.values) > 0 {
+			if s, changed := r.scrub(safeString(x)); changed {
+				return s, true
+			}
+		}
+		return v, false
+	case Logable:
+		return r.redactKeyvals(x.LogValues(), depth)
+	case []byte:
+		return v, false
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		// Other maps with string keys
+		if rv.Type().Key().Kind() == reflect.String {
+			kv := make([]interface{}, 0, rv.Len()*2)
+			iter := rv.MapRange()
+			for iter.Next() {
+				kv = append(kv, iter.Key().String(), iter.Value().Interface())
+			}
+			return r.redactKeyvals(kv, depth)
+		}
+	case reflect.Slice, reflect.Array:
+		return r.redactSlice(k, rv, depth)
+	}
+	return v, false
+}
+
+// If any of the elements needs redaction, return a redacted copy of the slice or array -
+// of the same type if the redacted values fit, otherwise a []interface{}.
+func (r *redactor) redactSlice(k interface{}, rv reflect.Value, depth int) (interface{}, bool) {
+	var out []interface{}
+	for i := 0; i < rv.Len(); i++ {
+		if nv, changed := r.redact(k, rv.Index(i).Interface(), depth+1); changed {
+			if out == nil {
+				out = make([]interface{}, rv.Len())
+				for j := 0; j < rv.Len(); j++ {
+					out[j] = rv.Index(j).Interface()
+				}
+			}
+			out[i] = nv
+		}
+	}
+	if out == nil {
+		return nil, false
+	}
+	if rv.Kind() == reflect.Slice {
+		typed := reflect.MakeSlice(rv.Type(), len(out), len(out))
+		for i, v := range out {
+			ev := reflect.ValueOf(v)
+			if !ev.IsValid() || !ev.Type().AssignableTo(rv.Type().Elem()) {
+				return out, true
+			}
+			typed.Index(i).Set(ev)
+		}
+		return typed.Interface(), true
+	}
+	return out, true
+}
+
+// If any of the values needs redaction, return a redacted copy
+func (r *redactor) redactKeyvals(kv []interface{}, depth int) (interface{}, bool) {
+	var out redactedKV
+	for i := 0; i+1 < len(kv); i += 2 {
+		if nv, changed := r.redact(kv[i], kv[i+1], depth+1); changed {
+			if out == nil {
+				out = make(redactedKV, len(kv)/2)
+			}
+			out[fmt.Sprint(kv[i])] = nv
+		}
+	}
+	if out == nil {
+		return nil, false
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		if _, ok := out[key]; !ok {
+			out[key] = kv[i+1]
+		}
+	}
+	return out, true
+}
@@ -0,0 +1,108 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type secret string
+
+func (s secret) Redact() interface{} {
+	return "s***"
+}
+
+func TestRedactHandler(t *testing.T) {
+	var b bytes.Buffer
+	redact := func(h log.Handler) log.Handler {
+		return log.RedactHandler(h,
+			log.RedactKeys("password", "Authorization"),
+			log.RedactValues(log.CardNumberPattern, log.BearerTokenPattern))
+	}
+	l := log.NewLogger(syslog.LOG_DEBUG, redact(log.NewStdFormatter(&b, "", 0)))
+
+	user := log.KV{"name": "joe", "db_password": "hunter2"}
+	header := http.Header{"Authorization": []string{"Bearer abc.def"}}
+	l.INFO("login", "user", user, "card", "paid with 4111 1111 1111 1111", "pin", secret("1234"),
+		"header", header, "note", "auth: Bearer xyz123")
+
+	expected := `login user.db_password=[REDACTED] user.name=joe card="paid with [REDACTED]" pin=s*** header.Authorization=[REDACTED] note="auth: [REDACTED]"` + "\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+	if user["db_password"] != "hunter2" {
+		t.Error("Original data modified")
+	}
+
+	b.Reset()
+	l.SetHandler(redact(log.NewJSONFormatter(&b)))
+	l.INFO("login", "user", log.KV{"name": "joe", "meta": log.KV{"PASSWORD": "x"}}, "n", 42)
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(m["user"])
+	if string(got) != `{"meta":{"PASSWORD":"[REDACTED]"},"name":"joe"}` || m["n"] != 42.0 {
+		t.Errorf("Unexpected output: %s", b.String())
+	}
+
+	// Value patterns apply to slice elements
+	b.Reset()
+	l.INFO("request", "header", http.Header{"X-Note": []string{"ok", "Bearer abc"}}, "list", []interface{}{1, "Bearer xyz"})
+	m = nil
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = json.Marshal([]interface{}{m["header"], m["list"]})
+	if string(got) != `[{"X-Note":["ok","[REDACTED]"]},[1,"[REDACTED]"]]` {
+		t.Errorf("Unexpected output: %s", b.String())
+	}
+}
+
+type recursive struct{}
+
+func (r recursive) Redact() interface{} {
+	return r
+}
+
+func TestRedactDepth(t *testing.T) {
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_DEBUG, log.RedactHandler(log.NewStdFormatter(&b, "", 0)))
+
+	l.INFO("deep", "r", recursive{})
+	if b.String() != "deep r=[REDACTED]\n" {
+		t.Errorf("Unexpected output: %q", b.String())
+	}
+
+	// Values not redacted are passed on as is
+	b.Reset()
+	l.INFO("kv", "user", log.KV{"name": "joe"})
+	b2 := b.String()
+	b.Reset()
+	log.NewLogger(syslog.LOG_DEBUG, log.NewStdFormatter(&b, "", 0)).INFO("kv", "user", log.KV{"name": "joe"})
+	if b2 != b.String() {
+		t.Errorf("Unredacted value formatted differently: %q, %q", b2, b.String())
+	}
+}
+
+type token string
+
+func (t token) Redact() interface{} { return "t***" }
+func (t token) String() string      { return string(t) }
+
+// Redacted maps are flattened by formatters rendering values as strings
+func TestRedactSyslog(t *testing.T) {
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_DEBUG, log.RedactHandler(log.NewSyslogFormatter(&b), log.RedactKeys("pw")))
+
+	l.INFO("login", "user", log.KV{"name": "joe", "pw": "x", "meta": log.KV{"tok": token("abc")}})
+	expected := `[kv@32473 user.meta.tok="t***" user.name="joe" user.pw="[REDACTED\]"] login` + "\n"
+	if !strings.HasSuffix(b.String(), expected) {
+		t.Errorf("Expected suffix %q, got %q", expected, b.String())
+	}
+}
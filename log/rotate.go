@@ -0,0 +1,334 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RotatingFileWriter is an EvWriter writing to a file, which is rotated when it
+// reaches a maximum size and/or at fixed wall-clock intervals.
+// On rotation the file is renamed to <path>.1, pushing older backups to <path>.2 etc.
+// Backups beyond the configured number are removed.
+// Optionally rotated files are gzip'ed in the background to <path>.N.gz. Files failing to
+// compress are kept uncompressed and the error is logged on the default Logger.
+//
+// Reopen() closes and reopens the file at path for use with external
+// tools (like logrotate) which rename the log file and signal the process.
+// All writes are serialized, so no lines are lost while rotating or reopening.
+type RotatingFileWriter struct {
+	path     string
+	perm     os.FileMode
+	maxsize  int64
+	interval time.Duration
+	backups  int
+	compress bool
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	next time.Time // next interval rotation
+
+	rotations  uint64        // number of times backups have been shifted
+	pending    []uint64      // rotations whose backups are waiting to be compressed
+	wake       chan struct{} // signals the compressor
+	compressed chan struct{} // closed when the compressor exits
+}
+
+// RotateOption configures a RotatingFileWriter
+type RotateOption func(*RotatingFileWriter)
+
+// RotateSize makes the file rotate before it would exceed size bytes. Default is no limit.
+func RotateSize(size int64) RotateOption {
+	return func(w *RotatingFileWriter) { w.maxsize = size }
+}
+
+// RotateInterval makes the file rotate whenever the wall clock passes a multiple of d
+// (counted from the zero time in UTC - so 24*time.Hour rotates at UTC midnight).
+func RotateInterval(d time.Duration) RotateOption {
+	return func(w *RotatingFileWriter) { w.interval = d }
+}
+
+// RotateBackups sets the number of rotated files to keep. Default is 5.
+func RotateBackups(n int) RotateOption {
+	return func(w *RotatingFileWriter) { w.backups = n }
+}
+
+// RotateCompress makes rotated files be gzip'ed.
+func RotateCompress(compress bool) RotateOption {
+	return func(w *RotatingFileWriter) { w.compress = compress }
+}
+
+// RotatePerm sets the permissions of created log files. Default is 0644.
+func RotatePerm(perm os.FileMode) RotateOption {
+	return func(w *RotatingFileWriter) { w.perm = perm }
+}
+
+// NewRotatingFileWriter opens (appending to) the file at path.
+func NewRotatingFileWriter(path string, options ...RotateOption) (w *RotatingFileWriter, err error) {
+	w = &RotatingFileWriter{
+		path:    path,
+		perm:    0644,
+		backups: 5,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err = w.open(); err != nil {
+		return nil, err
+	}
+	w.schedule(time.Now())
+	if w.compress {
+		w.wake = make(chan struct{}, 1)
+		w.compressed = make(chan struct{})
+		go w.compressor()
+	}
+	return
+}
+
+// open must be called with the lock held.
+// The old file (if any) is not closed before the new one is successfully opened.
+func (w *RotatingFileWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.perm)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.file = f
+	w.size = fi.Size()
+	return nil
+}
+
+func (w *RotatingFileWriter) schedule(now time.Time) {
+	if w.interval > 0 {
+		w.next = now.Truncate(w.interval).Add(w.interval)
+	}
+}
+
+// Write implements io.Writer, rotating the file first if needed.
+// If rotation fails, b is written to the current file and the rotation error returned.
+func (w *RotatingFileWriter) Write(b []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	var now time.Time
+	if w.interval > 0 {
+		now = time.Now()
+	}
+	if (w.interval > 0 && !now.Before(w.next)) ||
+		(w.maxsize > 0 && w.size > 0 && w.size+int64(len(b)) > w.maxsize) {
+		// If rotation fails, keep writing to the current file - but return the error.
+		rerr := w.rotate()
+		if w.interval > 0 {
+			w.schedule(now)
+		}
+		defer func() {
+			if err == nil {
+				err = rerr
+			}
+		}()
+	}
+
+	n, err = w.file.Write(b)
+	w.size += int64(n)
+	return
+}
+
+// EvWrite implements EvWriter.
+func (w *RotatingFileWriter) EvWrite(e Event, b []byte) (n int, err error) {
+	return w.Write(b)
+}
+
+// Rotate rotates the file now.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes the file and opens it again by path. If opening fails,
+// the old file is kept open and written to.
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.open()
+}
+
+// Close closes the file and waits for any background compression to finish.
+func (w *RotatingFileWriter) Close() (err error) {
+	w.mu.Lock()
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+		if w.wake != nil {
+			close(w.wake)
+		}
+	}
+	w.mu.Unlock()
+	if w.compressed != nil {
+		<-w.compressed
+	}
+	return
+}
+
+// backupName is the name of the n'th backup - without any .gz extension
+func (w *RotatingFileWriter) backupName(n int) string {
+	return w.path + "." + strconv.Itoa(n)
+}
+
+// rotate must be called with the lock held.
+func (w *RotatingFileWriter) rotate() error {
+	if w.backups <= 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+
+	// Move the file aside before shifting the backups, so they are left alone if that fails.
+	rotating := w.path + ".rotating"
+	if err := os.Rename(w.path, rotating); err != nil {
+		if os.IsNotExist(err) {
+			return w.open()
+		}
+		return err
+	}
+
+	// Backups not (yet) compressed are shifted too.
+	for _, ext := range []string{"", ".gz"} {
+		os.Remove(w.backupName(w.backups) + ext)
+		for n := w.backups - 1; n > 0; n-- {
+			os.Rename(w.backupName(n)+ext, w.backupName(n+1)+ext)
+		}
+	}
+	w.rotations++
+
+	if err := os.Rename(rotating, w.backupName(1)); err != nil {
+		os.Rename(rotating, w.path)
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	if w.compress {
+		w.pending = append(w.pending, w.rotations)
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// compressor gzips rotated files in the background, so writes don't wait for it.
+func (w *RotatingFileWriter) compressor() {
+	defer close(w.compressed)
+	for range w.wake {
+		for {
+			name, more, err := w.compressNext()
+			if !more {
+				break
+			}
+			if err != nil {
+				ERROR("Compressing log file failed", "file", name, "err", err)
+			}
+		}
+	}
+}
+
+// compressNext compresses the oldest backup waiting to be compressed, returning more=false
+// if there were none.
+// Backups may be shifted while being compressed, so the number of a backup is found by
+// counting the rotations since it was made.
+func (w *RotatingFileWriter) compressNext() (name string, more bool, err error) {
+	w.mu.Lock()
+	if len(w.pending) == 0 {
+		w.mu.Unlock()
+		return "", false, nil
+	}
+	rotation := w.pending[0]
+	w.pending = w.pending[1:]
+	n := int(w.rotations-rotation) + 1
+	if n > w.backups {
+		w.mu.Unlock()
+		return "", true, nil
+	}
+	name = w.backupName(n)
+	in, err := os.Open(name)
+	var out *os.File
+	if err == nil {
+		out, err = os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, w.perm)
+		if err != nil {
+			in.Close()
+		}
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return name, true, err
+	}
+
+	err = gzipFile(in, out)
+	in.Close()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n = int(w.rotations-rotation) + 1
+	if n > w.backups {
+		// Removed while compressing
+		return name, true, nil
+	}
+	name = w.backupName(n)
+	if err != nil {
+		os.Remove(name + ".gz")
+		return name, true, err
+	}
+	return name, true, os.Remove(name)
+}
+
+// gzipFile compresses in to out, closing out.
+func gzipFile(in io.Reader, out *os.File) (err error) {
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return
+}
+
+// ReopenFunc returns a function reopening all the given writers, suitable
+// as a gone/signals Action. Failures are logged on the default Logger.
+func ReopenFunc(writers ...*RotatingFileWriter) func() {
+	return func() {
+		for _, w := range writers {
+			if err := w.Reopen(); err != nil {
+				ERROR("Reopening log file failed", "file", w.path, "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package log_test
+
+import (
+	"compress/gzip"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotatingFileWriterSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.log")
+
+	w, err := log.NewRotatingFileWriter(path, log.RotateSize(10), log.RotateBackups(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewStdFormatter(w, "", 0))
+
+	for _, msg := range []string{"line1", "line2", "line3", "line4"} {
+		l.INFO(msg)
+	}
+	w.Close()
+
+	expected := map[string]string{
+		path:        "line4\n",
+		path + ".1": "line3\n",
+		path + ".2": "line2\n",
+	}
+	for file, content := range expected {
+		if got := readFile(t, file); got != content {
+			t.Errorf("%s: expected %q, got %q", file, content, got)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Too many backups kept")
+	}
+}
+
+func TestRotatingFileWriterReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.log")
+
+	w, err := log.NewRotatingFileWriter(path, log.RotateCompress(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewStdFormatter(w, "", 0))
+
+	l.INFO("before")
+	// like logrotate
+	if err = os.Rename(path, path+".old"); err != nil {
+		t.Fatal(err)
+	}
+	l.INFO("moved")
+	log.ReopenFunc(w)()
+	l.INFO("after")
+
+	if err = w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.INFO("rotated")
+	w.Close()
+
+	if got := readFile(t, path+".old"); got != "before\nmoved\n" {
+		t.Errorf("Unexpected content of moved file: %q", got)
+	}
+	if got := readFile(t, path); got != "rotated\n" {
+		t.Errorf("Unexpected content of log file: %q", got)
+	}
+
+	if got := readGzipFile(t, path+".1.gz"); got != "after\n" {
+		t.Errorf("Unexpected content of compressed backup: %q", got)
+	}
+}
+
+func readGzipFile(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// Uncompressed backups are shifted along with the compressed ones
+func TestRotatingFileWriterCompressLeftovers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.log")
+
+	if err = ioutil.WriteFile(path+".1", []byte("leftover\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := log.NewRotatingFileWriter(path, log.RotateCompress(true), log.RotateBackups(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewStdFormatter(w, "", 0))
+
+	// Rotating while previous backups are being compressed
+	for _, msg := range []string{"a", "b", "c"} {
+		l.INFO(msg)
+		if err = w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	if got := readFile(t, path+".4"); got != "leftover\n" {
+		t.Errorf("Unexpected content of uncompressed backup: %q", got)
+	}
+	for n, content := range []string{"c\n", "b\n", "a\n"} {
+		name := path + "." + strconv.Itoa(n+1)
+		if got := readGzipFile(t, name+".gz"); got != content {
+			t.Errorf("%s.gz: expected %q, got %q", name, content, got)
+		}
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("%s not removed after compression", name)
+		}
+	}
+}
+
+// A failed rotation leaves the backups alone and keeps writing to the file
+func TestRotatingFileWriterRotateFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.log")
+
+	w, err := log.NewRotatingFileWriter(path, log.RotateSize(10), log.RotateBackups(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := ioutil.WriteFile(path+".1", []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The file can't be moved aside onto a non-empty directory
+	if err := os.MkdirAll(filepath.Join(path+".rotating", "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write([]byte("line1\n"))
+	if n, err := w.Write([]byte("line2\n")); err == nil || n != 6 {
+		t.Errorf("Expected a rotation error, got %d, %v", n, err)
+	}
+	if got := readFile(t, path+".1"); got != "old\n" {
+		t.Errorf("Backup shifted: %q", got)
+	}
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Error("Backup shifted")
+	}
+	if got := readFile(t, path); got != "line1\nline2\n" {
+		t.Errorf("Unexpected content %q", got)
+	}
+}
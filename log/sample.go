@@ -0,0 +1,141 @@
+package log
+
+import (
+	"fmt"
+	"github.com/One-com/gone/log/syslog"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingHandler passes a sample of high volume events to another Handler.
+// Events at levels with a sample rate (see SampleRate) are counted per level and message
+// during a second. The first events in the second are passed, then only 1 in every M.
+// Events at levels without a sample rate are always passed.
+//
+// With SampleKey, events having KV data for the key are instead sampled by a hash of the value,
+// passing 1 in M values. This keeps all events for e.g. a request ID together.
+type SamplingHandler struct {
+	h     Handler
+	rates [8]sampleRate
+	key   string
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[rateKey]int
+
+	sampled [8]uint64 // discarded events per level
+}
+
+type sampleRate struct {
+	first      int
+	thereafter int // 0: no sampling
+}
+
+// SampleOption configures a SamplingHandler
+type SampleOption func(*SamplingHandler)
+
+// SampleRate makes the SamplingHandler pass the first events with the same message per second
+// at level and thereafter 1 in every thereafter events.
+// A thereafter value of 1 or less disables sampling for the level.
+func SampleRate(level syslog.Priority, first, thereafter int) SampleOption {
+	return func(s *SamplingHandler) {
+		if thereafter < 1 {
+			thereafter = 1
+		}
+		s.rates[level&0x07] = sampleRate{first: first, thereafter: thereafter}
+	}
+}
+
+// SampleKey makes the SamplingHandler sample events with a value for key deterministically by the
+// value - passing either all or none of the events for a value at a given level.
+func SampleKey(key string) SampleOption {
+	return func(s *SamplingHandler) {
+		s.key = key
+	}
+}
+
+// NewSamplingHandler creates a SamplingHandler passing sampled events to h.
+func NewSamplingHandler(h Handler, options ...SampleOption) *SamplingHandler {
+	s := &SamplingHandler{
+		h:      h,
+		counts: make(map[rateKey]int),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Log implements the Handler interface
+func (s *SamplingHandler) Log(e Event) error {
+	lvl := e.Lvl & 0x07
+	rate := s.rates[lvl]
+	if rate.thereafter <= 1 {
+		return s.h.Log(e)
+	}
+
+	if s.key != "" {
+		if v, ok := s.keyValue(e); ok {
+			if hashValue(v)%uint32(rate.thereafter) == 0 {
+				return s.h.Log(e)
+			}
+			atomic.AddUint64(&s.sampled[lvl], 1)
+			return nil
+		}
+	}
+
+	now := time.Now()
+	key := rateKey{lvl, e.Msg}
+	s.mu.Lock()
+	if now.Sub(s.start) >= time.Second {
+		s.start = now
+		s.counts = make(map[rateKey]int)
+	}
+	n := s.counts[key] + 1
+	s.counts[key] = n
+	s.mu.Unlock()
+
+	if n <= rate.first || (n-rate.first)%rate.thereafter == 0 {
+		return s.h.Log(e)
+	}
+	atomic.AddUint64(&s.sampled[lvl], 1)
+	return nil
+}
+
+// Find the value of the sampling key in the event KV data.
+func (s *SamplingHandler) keyValue(e Event) (string, bool) {
+	for i := 0; i+1 < len(e.Data); i += 2 {
+		if k, ok := e.Data[i].(string); ok && k == s.key {
+			switch v := e.Data[i+1].(type) {
+			case string:
+				return v, true
+			case Lazy:
+				return v.evaluate(), true
+			default:
+				return fmt.Sprint(v), true
+			}
+		}
+	}
+	return "", false
+}
+
+func hashValue(v string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(v))
+	return h.Sum32()
+}
+
+// Sampled returns the number of events discarded at level.
+func (s *SamplingHandler) Sampled(level syslog.Priority) uint64 {
+	return atomic.LoadUint64(&s.sampled[level&0x07])
+}
+
+// SampledTotal returns the number of events discarded at all levels.
+func (s *SamplingHandler) SampledTotal() (n uint64) {
+	for i := range s.sampled {
+		n += atomic.LoadUint64(&s.sampled[i])
+	}
+	return
+}
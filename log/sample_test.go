@@ -0,0 +1,55 @@
+package log_test
+
+import (
+	"fmt"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"strings"
+	"testing"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	out := newSyncBufHandler()
+	s := log.NewSamplingHandler(out, log.SampleRate(syslog.LOG_DEBUG, 2, 3))
+	l := log.NewLogger(syslog.LOG_DEBUG, s)
+
+	for i := 1; i <= 10; i++ {
+		l.DEBUG("tick", "n", i)
+	}
+	l.DEBUG("other")
+	l.INFO("info")
+
+	expected := "tick n=1\ntick n=2\ntick n=5\ntick n=8\nother\ninfo\n"
+	if got := out.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if s.Sampled(syslog.LOG_DEBUG) != 6 || s.SampledTotal() != 6 {
+		t.Errorf("Unexpected sample counts: %d, %d", s.Sampled(syslog.LOG_DEBUG), s.SampledTotal())
+	}
+}
+
+func TestSamplingHandlerKey(t *testing.T) {
+	out := newSyncBufHandler()
+	s := log.NewSamplingHandler(out, log.SampleRate(syslog.LOG_DEBUG, 0, 4), log.SampleKey("request_id"))
+	l := log.NewLogger(syslog.LOG_DEBUG, s)
+
+	const requests = 100
+	for i := 0; i < requests; i++ {
+		rl := l.With("request_id", fmt.Sprintf("req-%d", i))
+		rl.DEBUG("start")
+		rl.DEBUG("end")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines)%2 != 0 || len(lines) == 0 || len(lines) == 2*requests {
+		t.Fatalf("Unexpected sampling: %q", lines)
+	}
+	for i := 0; i < len(lines); i += 2 {
+		if !strings.HasPrefix(lines[i], "start") || lines[i+1] != strings.Replace(lines[i], "start", "end", 1) {
+			t.Errorf("Request lines not kept together: %q, %q", lines[i], lines[i+1])
+		}
+	}
+	if s.SampledTotal() != uint64(2*requests-len(lines)) {
+		t.Errorf("Unexpected sample count: %d", s.SampledTotal())
+	}
+}
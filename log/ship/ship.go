@@ -0,0 +1,395 @@
+// Package ship provides a Handler shipping gone/log events to a log collector over HTTP.
+//
+// Events are JSON encoded (one object per line) and POSTed in batches - like to a
+// Loki push proxy or an Elasticsearch bulk style endpoint.
+// Failed batches are retried with exponential backoff. If the collector stays unreachable,
+// batches are spooled to a bounded on-disk queue, which is replayed when the collector
+// is reachable again - also by the next process using the same spool directory.
+// While the collector is unreachable, replaying is retried with exponential backoff.
+package ship
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/One-com/gone/log"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Handler batches events and ships them to a collector URL.
+type Handler struct {
+	url       string
+	client    *http.Client
+	header    http.Header
+	batchSize int
+	interval  time.Duration
+	retries   int
+	backoff   time.Duration
+	spoolDir  string
+	spoolMax  int64
+	fopts     []log.HandlerOption
+
+	mu     sync.Mutex // protects the current batch and the closed flag
+	buf    *bytes.Buffer
+	f      log.Handler
+	closed bool
+
+	smu  sync.Mutex // protects the spool directory
+	sseq uint64
+
+	batches chan []byte   // to the shipper
+	spoolq  chan []byte   // to the spooler, when the shipper is behind
+	done    chan struct{} // closed when the shipper exits
+	spooled chan struct{} // closed when the spooler exits
+	dropped uint64
+
+	// Used by the shipper only
+	replayWait time.Duration
+	nextReplay time.Time
+}
+
+// The max wait between replaying the spool while the collector is failing
+const maxReplayWait = time.Minute
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// BatchSize sets the number of bytes of encoded events making a batch be shipped
+// without waiting for the batch interval. Default is 1MB.
+func BatchSize(size int) Option {
+	return func(h *Handler) { h.batchSize = size }
+}
+
+// BatchInterval sets the max time events are batched before being shipped. Default is 1 second.
+func BatchInterval(d time.Duration) Option {
+	return func(h *Handler) { h.interval = d }
+}
+
+// Retries sets the number of times a failed POST is retried before the batch is spooled. Default 3.
+func Retries(n int) Option {
+	return func(h *Handler) { h.retries = n }
+}
+
+// Backoff sets the wait before the first retry. It's doubled for each further retry. Default 100ms.
+func Backoff(d time.Duration) Option {
+	return func(h *Handler) { h.backoff = d }
+}
+
+// Spool enables spooling batches which can't be shipped to files in dir, keeping at most
+// maxBytes of batches. The oldest batches are discarded to make room.
+func Spool(dir string, maxBytes int64) Option {
+	return func(h *Handler) {
+		h.spoolDir = dir
+		h.spoolMax = maxBytes
+	}
+}
+
+// Client sets the http.Client used to POST batches. Default has a timeout of 10 seconds.
+func Client(c *http.Client) Option {
+	return func(h *Handler) { h.client = c }
+}
+
+// Header adds a header to the POST requests (like Authorization).
+func Header(key, value string) Option {
+	return func(h *Handler) { h.header.Add(key, value) }
+}
+
+// FormatterOptions are applied to the JSON formatter encoding the events (like log.KeyNamesOpt).
+func FormatterOptions(options ...log.HandlerOption) Option {
+	return func(h *Handler) { h.fopts = append(h.fopts, options...) }
+}
+
+// ErrSpoolFull is returned when a batch is larger than the spool size.
+var ErrSpoolFull = errors.New("Batch exceeds spool size")
+
+// New creates a Handler shipping events to url and starts shipping leftover
+// batches from the spool directory, if any.
+// Call Close() to ship the remaining events on shutdown.
+func New(url string, options ...Option) (*Handler, error) {
+	h := &Handler{
+		url:       url,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		header:    make(http.Header),
+		batchSize: 1 << 20,
+		interval:  time.Second,
+		retries:   3,
+		backoff:   100 * time.Millisecond,
+		buf:       new(bytes.Buffer),
+		batches:   make(chan []byte, 16),
+		spoolq:    make(chan []byte, 16),
+		done:      make(chan struct{}),
+		spooled:   make(chan struct{}),
+	}
+	h.header.Set("Content-Type", "application/x-ndjson")
+	for _, option := range options {
+		option(h)
+	}
+	if h.spoolDir != "" {
+		if err := os.MkdirAll(h.spoolDir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	h.f = log.NewJSONFormatter(h.buf, h.fopts...)
+
+	go h.run()
+	go h.spooler()
+	return h, nil
+}
+
+// Log implements log.Handler by adding the event to the current batch.
+func (h *Handler) Log(e log.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return log.ErrHandlerClosed
+	}
+	if err := h.f.Log(e); err != nil {
+		return err
+	}
+	if h.buf.Len() >= h.batchSize {
+		b := h.cut()
+		select {
+		case h.batches <- b:
+			return nil
+		default:
+		}
+		// The shipper is behind. Don't block logging.
+		select {
+		case h.spoolq <- b:
+		default:
+			// So is the spooler.
+			atomic.AddUint64(&h.dropped, 1)
+		}
+	}
+	return nil
+}
+
+// cut must be called with the lock held
+func (h *Handler) cut() []byte {
+	if h.buf.Len() == 0 {
+		return nil
+	}
+	b := make([]byte, h.buf.Len())
+	copy(b, h.buf.Bytes())
+	h.buf.Reset()
+	return b
+}
+
+// Dropped returns the number of batches discarded - because they couldn't be shipped
+// or spooled, or were pushed out of the spool.
+func (h *Handler) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Close ships the current batch and stops the Handler.
+// Batches which can't be shipped are spooled.
+func (h *Handler) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return log.ErrHandlerClosed
+	}
+	h.closed = true
+	b := h.cut()
+	h.mu.Unlock()
+
+	if b != nil {
+		h.batches <- b
+	}
+	close(h.batches)
+	<-h.done
+	close(h.spoolq)
+	<-h.spooled
+	return nil
+}
+
+func (h *Handler) run() {
+	defer close(h.done)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	h.replay()
+	for {
+		select {
+		case b, ok := <-h.batches:
+			if !ok {
+				return
+			}
+			h.ship(b)
+		case <-ticker.C:
+			h.mu.Lock()
+			b := h.cut()
+			h.mu.Unlock()
+			if b != nil {
+				h.ship(b)
+			} else if !time.Now().Before(h.nextReplay) {
+				h.replay()
+			}
+		}
+	}
+}
+
+// spooler spools batches the shipper couldn't take, so logging doesn't wait for the disk.
+func (h *Handler) spooler() {
+	defer close(h.spooled)
+	for b := range h.spoolq {
+		h.spool(b)
+	}
+}
+
+// ship a batch, spooling it on failure. On success try shipping spooled batches.
+func (h *Handler) ship(b []byte) {
+	if err := h.post(b); err != nil {
+		if err != errRejected {
+			h.spool(b)
+		}
+		return
+	}
+	h.replay()
+}
+
+var errRejected = errors.New("Batch rejected")
+
+// post a batch with retries
+func (h *Handler) post(b []byte) (err error) {
+	wait := h.backoff
+	for attempt := 0; ; attempt++ {
+		err = h.postOnce(b)
+		if err == nil {
+			return
+		}
+		if err == errRejected {
+			// No point in retrying
+			atomic.AddUint64(&h.dropped, 1)
+			return
+		}
+		if attempt >= h.retries {
+			return
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+func (h *Handler) postOnce(b []byte) error {
+	req, err := http.NewRequest("POST", h.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, v := range h.header {
+		req.Header[k] = v
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return errRejected
+	}
+	return fmt.Errorf("Collector returned %s", resp.Status)
+}
+
+// spooled batch files sorted oldest first.
+func (h *Handler) spoolFiles() (files []string) {
+	names, _ := filepath.Glob(filepath.Join(h.spoolDir, "*.ndjson"))
+	sort.Strings(names)
+	return names
+}
+
+// spool a batch to disk, discarding the oldest spooled batches to make room.
+func (h *Handler) spool(b []byte) {
+	if h.spoolDir == "" {
+		atomic.AddUint64(&h.dropped, 1)
+		return
+	}
+	h.smu.Lock()
+	defer h.smu.Unlock()
+
+	if err := h.makeRoom(int64(len(b))); err != nil {
+		atomic.AddUint64(&h.dropped, 1)
+		return
+	}
+	h.sseq++
+	name := filepath.Join(h.spoolDir, fmt.Sprintf("%020d-%06d.ndjson", time.Now().UnixNano(), h.sseq%1000000))
+	tmp := strings.TrimSuffix(name, ".ndjson") + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		os.Remove(tmp)
+		atomic.AddUint64(&h.dropped, 1)
+		return
+	}
+	os.Rename(tmp, name)
+}
+
+// makeRoom must be called with the spool lock held
+func (h *Handler) makeRoom(size int64) error {
+	if size > h.spoolMax {
+		return ErrSpoolFull
+	}
+	files := h.spoolFiles()
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, file := range files {
+		if fi, err := os.Stat(file); err == nil {
+			sizes[i] = fi.Size()
+			total += sizes[i]
+		}
+	}
+	for i := 0; total+size > h.spoolMax && i < len(files); i++ {
+		if os.Remove(files[i]) == nil {
+			total -= sizes[i]
+			atomic.AddUint64(&h.dropped, 1)
+		}
+	}
+	return nil
+}
+
+// replay ships spooled batches, oldest first, until one fails.
+// Then replaying is backed off, doubling the wait from the batch interval up to maxReplayWait.
+func (h *Handler) replay() {
+	if h.spoolDir == "" {
+		return
+	}
+	h.smu.Lock()
+	files := h.spoolFiles()
+	h.smu.Unlock()
+
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue // pushed out of the spool
+		}
+		err = h.postOnce(b)
+		if err != nil && err != errRejected {
+			if h.replayWait == 0 {
+				h.replayWait = h.interval
+			} else if h.replayWait *= 2; h.replayWait > maxReplayWait {
+				h.replayWait = maxReplayWait
+			}
+			h.nextReplay = time.Now().Add(h.replayWait)
+			return
+		}
+		if err == errRejected {
+			atomic.AddUint64(&h.dropped, 1)
+		}
+		h.smu.Lock()
+		os.Remove(file)
+		h.smu.Unlock()
+	}
+	h.replayWait = 0
+}
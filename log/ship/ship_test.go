@@ -0,0 +1,157 @@
+package ship_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/ship"
+	"github.com/One-com/gone/log/syslog"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector records the messages of POSTed events, failing the first requests
+type collector struct {
+	mu    sync.Mutex
+	fail  int
+	posts int
+	msgs  []string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.posts++
+	if c.fail > 0 {
+		c.fail--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	s := bufio.NewScanner(r.Body)
+	for s.Scan() {
+		var m map[string]interface{}
+		json.Unmarshal(s.Bytes(), &m)
+		c.msgs = append(c.msgs, m["_msg"].(string))
+	}
+}
+
+func (c *collector) messages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.msgs...)
+}
+
+func TestShipRetry(t *testing.T) {
+	c := &collector{fail: 2}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	h, err := ship.New(srv.URL, ship.BatchSize(100), ship.Backoff(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := log.NewLogger(syslog.LOG_DEBUG, h)
+	for _, msg := range []string{"one", "two", "three"} {
+		l.INFO(msg, "padding", "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx")
+	}
+	h.Close()
+
+	if got := c.messages(); len(got) != 3 || got[0] != "one" || got[2] != "three" {
+		t.Errorf("Unexpected messages shipped: %v", got)
+	}
+	if c.posts != 5 || h.Dropped() != 0 {
+		t.Errorf("Expected 5 POSTs and no drops, got %d, %d", c.posts, h.Dropped())
+	}
+	if h.Log(log.Event{}) != log.ErrHandlerClosed {
+		t.Error("Closed Handler accepted events")
+	}
+}
+
+func TestShipSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// An unreachable collector
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	h, err := ship.New(down.URL, ship.Retries(1), ship.Backoff(time.Millisecond), ship.Spool(dir, 1<<16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := log.NewLogger(syslog.LOG_DEBUG, h)
+	l.ERROR("spooled")
+	h.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.ndjson"))
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 spooled batch, got %v", files)
+	}
+	b, _ := ioutil.ReadFile(files[0])
+	if !bytes.Contains(b, []byte(`"spooled"`)) {
+		t.Errorf("Unexpected spool content: %s", b)
+	}
+
+	// Replayed on startup
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	h, err = ship.New(srv.URL, ship.Spool(dir, 1<<16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+	if got := c.messages(); len(got) != 1 || got[0] != "spooled" {
+		t.Errorf("Spool not replayed: %v", got)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.ndjson")); len(files) != 0 {
+		t.Errorf("Spool not emptied: %v", files)
+	}
+}
+
+func TestShipReplayBackoff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "0.ndjson"), []byte(`{"_msg":"old"}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &collector{fail: 1000}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	h, err := ship.New(srv.URL, ship.Spool(dir, 1<<16), ship.BatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	// Replays at 0, 10, 30, 70, 150 and 310ms
+	c.mu.Lock()
+	posts := c.posts
+	c.fail = 0
+	c.mu.Unlock()
+	if posts < 2 || posts > 8 {
+		t.Errorf("Expected replays to back off, got %d POSTs", posts)
+	}
+
+	// Replayed immediately on a successful POST
+	l := log.NewLogger(syslog.LOG_DEBUG, h)
+	l.INFO("new")
+	h.Close()
+	if got := c.messages(); len(got) != 2 || got[0] != "new" || got[1] != "old" {
+		t.Errorf("Unexpected messages shipped: %v", got)
+	}
+}
@@ -0,0 +1,231 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"github.com/One-com/gone/log/syslog"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// Bridging between gone/log and the standard library log/slog package.
+
+// LevelFromSlog maps a slog level to a syslog level.
+// Levels between the slog named levels map to the syslog level below (more verbose).
+// NOTICE is slog.LevelInfo+2. Levels above slog.LevelError map to CRIT, ALERT and EMERG in steps of 4.
+func LevelFromSlog(level slog.Level) syslog.Priority {
+	switch {
+	case level >= slog.LevelError+12:
+		return syslog.LOG_EMERG
+	case level >= slog.LevelError+8:
+		return syslog.LOG_ALERT
+	case level >= slog.LevelError+4:
+		return syslog.LOG_CRIT
+	case level >= slog.LevelError:
+		return syslog.LOG_ERR
+	case level >= slog.LevelWarn:
+		return syslog.LOG_WARNING
+	case level >= slog.LevelInfo+2:
+		return syslog.LOG_NOTICE
+	case level >= slog.LevelInfo:
+		return syslog.LOG_INFO
+	}
+	return syslog.LOG_DEBUG
+}
+
+// LevelToSlog maps a syslog level to a slog level. It's the inverse of LevelFromSlog.
+func LevelToSlog(level syslog.Priority) slog.Level {
+	switch level & 0x07 {
+	case syslog.LOG_EMERG:
+		return slog.LevelError + 12
+	case syslog.LOG_ALERT:
+		return slog.LevelError + 8
+	case syslog.LOG_CRIT:
+		return slog.LevelError + 4
+	case syslog.LOG_ERR:
+		return slog.LevelError
+	case syslog.LOG_WARNING:
+		return slog.LevelWarn
+	case syslog.LOG_NOTICE:
+		return slog.LevelInfo + 2
+	case syslog.LOG_INFO:
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+//---
+
+// slogAdapter is a slog.Handler logging to a gone Logger
+type slogAdapter struct {
+	l     *Logger
+	group string // prefix of keys
+}
+
+// NewSlogAdapter returns a slog.Handler logging to the Logger, so libraries using log/slog
+// can log through gone/log:
+//
+//	slog.SetDefault(slog.New(log.NewSlogAdapter(log.Default())))
+//
+// Attributes in groups are logged with the keys prefixed by the group names and a ".".
+// slog.LogValuer values are resolved. Values of type Lazy are passed on to be evaluated by the Formatter.
+// Values registered with RegisterContextKey are added from the context.
+func NewSlogAdapter(l *Logger) slog.Handler {
+	return &slogAdapter{l: l}
+}
+
+// Enabled implements slog.Handler
+func (a *slogAdapter) Enabled(ctx context.Context, level slog.Level) bool {
+	return a.l.Does(LevelFromSlog(level))
+}
+
+// Handle implements slog.Handler
+func (a *slogAdapter) Handle(ctx context.Context, r slog.Record) error {
+	level := LevelFromSlog(r.Level)
+	if !a.l.Does(level) {
+		return nil
+	}
+
+	kv := contextKV(ctx)
+	r.Attrs(func(attr slog.Attr) bool {
+		kv = appendSlogAttr(kv, a.group, attr)
+		return true
+	})
+
+	e := a.l.newEvent(0, level, r.Message, normalize(kv))
+	if !r.Time.IsZero() {
+		e.time = r.Time
+		e.tok = true
+	}
+	// The call stack is slog's. Use the caller recorded by slog.
+	if e.fok {
+		e.fok = false
+		if r.PC != 0 {
+			frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+			e.file, e.line, e.fok = frame.File, frame.Line, true
+		}
+	}
+	if e.stack != nil {
+		e.stack = stackFrom(e.stack, r.PC)
+	}
+	return a.l.h.Log(e)
+}
+
+// stackFrom trims stack to start with the frame of pc - or is just pc if it's not in stack.
+func stackFrom(stack []uintptr, pc uintptr) []uintptr {
+	if pc == 0 {
+		return nil
+	}
+	for i, p := range stack {
+		if p == pc {
+			return stack[i:]
+		}
+	}
+	return []uintptr{pc}
+}
+
+// WithAttrs implements slog.Handler by creating a child Logger with the attributes as KV data.
+func (a *slogAdapter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var kv []interface{}
+	for _, attr := range attrs {
+		kv = appendSlogAttr(kv, a.group, attr)
+	}
+	if len(kv) == 0 {
+		return a
+	}
+	return &slogAdapter{l: a.l.With(kv...), group: a.group}
+}
+
+// WithGroup implements slog.Handler
+func (a *slogAdapter) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return a
+	}
+	return &slogAdapter{l: a.l, group: a.group + name + "."}
+}
+
+// Flatten an attribute to key/value pairs.
+func appendSlogAttr(kv []interface{}, prefix string, attr slog.Attr) []interface{} {
+	v := attr.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix = prefix + attr.Key + "."
+		}
+		for _, ga := range v.Group() {
+			kv = appendSlogAttr(kv, prefix, ga)
+		}
+		return kv
+	}
+	if attr.Key == "" && v.Any() == nil {
+		return kv // empty attributes are ignored
+	}
+	val := v.Any()
+	if l, ok := val.(Logable); ok {
+		// Expand as a group
+		vals := l.LogValues()
+		for i := 0; i+1 < len(vals); i += 2 {
+			kv = append(kv, prefix+attr.Key+"."+fmt.Sprint(vals[i]), vals[i+1])
+		}
+		return kv
+	}
+	return append(kv, prefix+attr.Key, val)
+}
+
+//---
+
+// SlogForwardHandler returns a Handler passing events on to a slog.Handler, so gone/log
+// events can be logged by log/slog Handlers.
+// Events are converted to slog records, with the Logger name as the attribute "logger".
+// Lazy values are resolved when logged by slog. Logable values become groups.
+// File/line info is not forwarded, since slog needs the program counter.
+func SlogForwardHandler(h slog.Handler) Handler {
+	return HandlerFunc(func(e Event) error {
+		level := LevelToSlog(e.Lvl)
+		ctx := context.Background()
+		if !h.Enabled(ctx, level) {
+			return nil
+		}
+		t := e.time
+		if !e.tok {
+			t = time.Now()
+		}
+		r := slog.NewRecord(t, level, e.Msg, 0)
+		if e.Name != "" {
+			r.AddAttrs(slog.String("logger", e.Name))
+		}
+		for i := 0; i+1 < len(e.Data); i += 2 {
+			r.AddAttrs(slogAttr(e.Data[i], e.Data[i+1]))
+		}
+		return h.Handle(ctx, r)
+	})
+}
+
+// slog.LogValuer evaluating a Lazy
+type lazyValuer Lazy
+
+func (l lazyValuer) LogValue() slog.Value {
+	return slog.AnyValue(l())
+}
+
+func slogAttr(k, v interface{}) slog.Attr {
+	var key string
+	if k != nil {
+		key = fmt.Sprint(k)
+	}
+	switch val := v.(type) {
+	case Lazy:
+		return slog.Any(key, lazyValuer(val))
+	case Logable:
+		vals := val.LogValues()
+		attrs := make([]interface{}, 0, len(vals)/2)
+		for i := 0; i+1 < len(vals); i += 2 {
+			attrs = append(attrs, slogAttr(vals[i], vals[i+1]))
+		}
+		return slog.Group(key, attrs...)
+	}
+	return slog.Any(key, v)
+}
@@ -0,0 +1,86 @@
+//go:build go1.21
+// +build go1.21
+
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogAdapter(t *testing.T) {
+	var b bytes.Buffer
+	l := log.NewLogger(syslog.LOG_INFO, log.NewStdFormatter(&b, "", log.Llevel))
+	sl := slog.New(log.NewSlogAdapter(l))
+
+	sl.Debug("filtered")
+	sl.With("a", 1).WithGroup("g").Warn("warn", "b", 2, slog.Group("h", "c", 3),
+		"lazy", log.Lazy(func() interface{} { return "evaluated" }),
+		"kv", log.KV{"d": 4})
+
+	expected := "<4>warn a=1 g.b=2 g.h.c=3 g.lazy=evaluated g.kv.d=4\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+
+	b.Reset()
+	sl.Log(context.Background(), slog.LevelInfo+2, "notice")
+	sl.Log(context.Background(), slog.LevelError+4, "crit")
+	if b.String() != "<5>notice\n<2>crit\n" {
+		t.Errorf("Unexpected level mapping: %q", b.String())
+	}
+
+	b.Reset()
+	l.DoCodeInfo(true)
+	l.ApplyHandlerOptions(log.FlagsOpt(log.Lshortfile))
+	sl.Info("code")
+	if !strings.HasPrefix(b.String(), "slog_test.go:") {
+		t.Errorf("Expected caller of slog, got %q", b.String())
+	}
+
+	b.Reset()
+	l.DoCodeInfo(false)
+	l.DoStackTrace(syslog.LOG_INFO, true)
+	slogWithStack(sl)
+	lines := strings.Split(b.String(), "\n")
+	if len(lines) < 4 || !strings.HasSuffix(lines[1], "log_test.slogWithStack") ||
+		!strings.HasSuffix(lines[3], "log_test.TestSlogAdapter") {
+		t.Errorf("Expected stack trace starting with the caller of slog, got %q", b.String())
+	}
+}
+
+func slogWithStack(sl *slog.Logger) {
+	sl.Info("with stack")
+}
+
+func TestSlogForwardHandler(t *testing.T) {
+	var b bytes.Buffer
+	sh := slog.NewTextHandler(&b, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := log.NewLogger(syslog.LOG_DEBUG, log.SlogForwardHandler(sh))
+
+	l.DEBUG("filtered")
+	l.With("a", 1).NOTICE("hello",
+		"lazy", log.Lazy(func() interface{} { return "evaluated" }),
+		"kv", log.KV{"d": 4})
+
+	expected := "level=INFO+2 msg=hello a=1 lazy=evaluated kv.d=4\n"
+	if got := b.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if strings.Contains(b.String(), "filtered") {
+		t.Error("Disabled level forwarded")
+	}
+}
@@ -36,6 +36,8 @@ type StdlibAdapter struct {
 	timestampKey string
 	fileKey      string
 	messageKey   string
+	detect       bool           // detect levels from common message prefixes
+	levelRegexp  *regexp.Regexp // detect levels by a "level" subexpression
 }
 
 // StdlibAdapterOption sets a parameter for the StdlibAdapter.
@@ -61,6 +63,24 @@ func Parse() StdlibAdapterOption {
 	return func(a *StdlibAdapter) { a.parse = true }
 }
 
+// DetectLevel makes the adapter log messages with a level prefix like "[ERROR]", "WARN:"
+// or "<3>" at that level - with the prefix removed.
+// Level names are as understood by ParseLevel - and "fatal" (CRIT), "panic" (CRIT, not EMERG)
+// and "trace" (DEBUG).
+// Messages with a detected level the Logger doesn't do are dropped.
+// Messages without a level prefix are logged at the level given to NewStdlibAdapter.
+// Unless Parse() is used, the stdlib Logger should have no flags, so the prefix starts the line.
+func DetectLevel() StdlibAdapterOption {
+	return func(a *StdlibAdapter) { a.detect = true }
+}
+
+// LevelRegexp makes the adapter take the level of messages matching re from the subexpression
+// named "level", like `level=(?P<level>\w+)`. The matched text is removed from the message.
+// It's tried before any prefixes enabled by DetectLevel.
+func LevelRegexp(re *regexp.Regexp) StdlibAdapterOption {
+	return func(a *StdlibAdapter) { a.levelRegexp = re }
+}
+
 // NewStdlibAdapter returns a new StdlibAdapter wrapper around the passed
 // logger. It's designed to be passed to the standard library's log.SetOutput()
 func NewStdlibAdapter(logger *Logger, level syslog.Priority, options ...StdlibAdapterOption) io.Writer {
@@ -104,7 +124,14 @@ func (a StdlibAdapter) Write(p []byte) (int, error) {
 	} else {
 		msg = string(p)
 	}
-	if err := a.gonelogger.log(a.level, msg, keyvals...); err != nil {
+	level := a.level
+	if a.levelRegexp != nil || a.detect {
+		var detected bool
+		if level, msg, detected = a.detectLevel(msg); detected && !a.gonelogger.Does(level) {
+			return len(p), nil
+		}
+	}
+	if err := a.gonelogger.log(level, msg, keyvals...); err != nil {
 		return 0, err
 	}
 	return len(p), nil
@@ -121,6 +148,59 @@ var (
 	logRegexp = regexp.MustCompile(logRegexpDate + logRegexpTime + logRegexpFile + logRegexpMsg)
 )
 
+var (
+	// "<3>", "[ERROR]", "[ERROR]:" or "ERROR:"
+	levelPrefixRegexp = regexp.MustCompile(`^\s*(?:<([0-7])>|\[([A-Za-z]+)\]:?|([A-Za-z]+):)\s*`)
+
+	stdlibLevelAliases = map[string]syslog.Priority{
+		"fatal": syslog.LOG_CRIT,
+		"panic": syslog.LOG_CRIT, // a Go panic is not a system wide emergency
+		"trace": syslog.LOG_DEBUG,
+	}
+)
+
+func parseStdlibLevel(name string) (syslog.Priority, bool) {
+	if lvl, ok := stdlibLevelAliases[strings.ToLower(name)]; ok {
+		return lvl, true
+	}
+	lvl, err := ParseLevel(name)
+	return lvl, err == nil
+}
+
+// Find the level of a message, returning the message without the level and whether
+// a level was found.
+func (a StdlibAdapter) detectLevel(msg string) (syslog.Priority, string, bool) {
+	if re := a.levelRegexp; re != nil {
+		if m := re.FindStringSubmatchIndex(msg); m != nil {
+			for i, name := range re.SubexpNames() {
+				if name != "level" || m[2*i] < 0 {
+					continue
+				}
+				if lvl, ok := parseStdlibLevel(msg[m[2*i]:m[2*i+1]]); ok {
+					before, after := strings.TrimRight(msg[:m[0]], " "), strings.TrimLeft(msg[m[1]:], " ")
+					if before != "" && after != "" {
+						return lvl, before + " " + after, true
+					}
+					return lvl, before + after, true
+				}
+			}
+		}
+	}
+	if a.detect {
+		if m := levelPrefixRegexp.FindStringSubmatchIndex(msg); m != nil {
+			for i := 1; i <= 3; i++ {
+				if m[2*i] < 0 {
+					continue
+				}
+				if lvl, ok := parseStdlibLevel(msg[m[2*i]:m[2*i+1]]); ok {
+					return lvl, msg[m[1]:], true
+				}
+			}
+		}
+	}
+	return a.level, msg, false
+}
+
 func subexps(line []byte) map[string]string {
 	m := logRegexp.FindSubmatch(line)
 	if len(m) < len(logRegexp.SubexpNames()) {
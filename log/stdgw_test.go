@@ -0,0 +1,82 @@
+package log_test
+
+import (
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/logtest"
+	"github.com/One-com/gone/log/syslog"
+	stdlog "log"
+	"regexp"
+	"testing"
+)
+
+func TestStdlibAdapterLevels(t *testing.T) {
+	rec := logtest.NewRecorder()
+	l := log.NewLogger(syslog.LOG_DEBUG, rec)
+
+	w := log.NewStdlibAdapter(l, syslog.LOG_NOTICE, log.Parse(), log.DetectLevel(),
+		log.LevelRegexp(regexp.MustCompile(`severity=(?P<level>\w+)`)))
+	std := stdlog.New(w, "", stdlog.LstdFlags)
+
+	std.Print("[ERROR] failed")
+	std.Print("WARN: careful")
+	std.Print("<7>details")
+	std.Print("[FATAL]: dying")
+	std.Print("panic: runtime error")
+	std.Print("request done severity=info took=1s")
+	std.Print("Note: nothing special")
+	std.Print("plain")
+
+	expected := []struct {
+		lvl syslog.Priority
+		msg string
+	}{
+		{syslog.LOG_ERR, "failed"},
+		{syslog.LOG_WARN, "careful"},
+		{syslog.LOG_DEBUG, "details"},
+		{syslog.LOG_CRIT, "dying"},
+		{syslog.LOG_CRIT, "runtime error"},
+		{syslog.LOG_INFO, "request done took=1s"},
+		{syslog.LOG_NOTICE, "Note: nothing special"},
+		{syslog.LOG_NOTICE, "plain"},
+	}
+	entries := rec.Entries()
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), entries)
+	}
+	for i, e := range expected {
+		if entries[i].Level != e.lvl || entries[i].Msg != e.msg {
+			t.Errorf("Expected %s %q, got %s", log.LevelName(e.lvl), e.msg, entries[i])
+		}
+		if _, ok := entries[i].Value("ts"); !ok {
+			t.Errorf("Timestamp not parsed: %s", entries[i])
+		}
+	}
+}
+
+// Detected levels the Logger doesn't do are dropped
+func TestStdlibAdapterDoes(t *testing.T) {
+	rec := logtest.NewRecorder()
+	l := log.NewLogger(syslog.LOG_INFO, rec)
+
+	std := stdlog.New(log.NewStdlibAdapter(l, syslog.LOG_NOTICE, log.Parse(), log.DetectLevel()), "", 0)
+	std.Print("[DEBUG] details")
+	std.Print("[INFO] info")
+
+	if entries := rec.Entries(); len(entries) != 1 || entries[0].Msg != "info" {
+		t.Errorf("Unexpected events: %v", entries)
+	}
+}
+
+// The fixed level of the adapter is logged even if the Logger doesn't do it
+func TestStdlibAdapterFixedLevel(t *testing.T) {
+	rec := logtest.NewRecorder()
+	l := log.NewLogger(syslog.LOG_WARN, rec)
+
+	stdlog.New(log.NewStdlibAdapter(l, syslog.LOG_INFO, log.Parse()), "", 0).Print("fixed")
+	stdlog.New(log.NewStdlibAdapter(l, syslog.LOG_INFO, log.Parse(), log.DetectLevel()), "", 0).Print("undetected")
+
+	entries := rec.Entries()
+	if len(entries) != 2 || entries[0].Msg != "fixed" || entries[1].Msg != "undetected" || entries[0].Level != syslog.LOG_INFO {
+		t.Errorf("Unexpected events: %v", entries)
+	}
+}
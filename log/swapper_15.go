@@ -42,6 +42,9 @@ func (s *swapper) Log(e *event) (err error) {
 	// Logger swappers *has* to have a valid valueStruct
 
 	if v.Handler != nil {
+		if _, ok := v.Handler.(fieldHandler); !ok {
+			e.materialize()
+		}
 		err = v.Handler.Log(Event{e})
 		if err == nil {
 			freePoolEvent(e)
@@ -50,17 +53,21 @@ func (s *swapper) Log(e *event) (err error) {
 	}
 	// Either no handler, or an error was returned
 	// Have to try parents. Walk the name-tree to find the first handler, not returning an error
-	cur := v.parent.h
+	// The root and unnamed Loggers have no parent.
+	cur := v.parent
 	for cur != nil {
-		v, _ := cur.val.Load().(valueStruct) // must be valid
+		v, _ := cur.h.val.Load().(valueStruct) // must be valid
 		if v.Handler != nil {
+			if _, ok := v.Handler.(fieldHandler); !ok {
+				e.materialize()
+			}
 			err = v.Handler.Log(Event{e})
 			if err == nil {
 				freePoolEvent(e)
 				return
 			}
 		}
-		cur = v.parent.h
+		cur = v.parent
 	}
 
 	freePoolEvent(e)
@@ -0,0 +1,278 @@
+package log
+
+import (
+	"fmt"
+	"github.com/One-com/gone/log/syslog"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// syslogformatter formats events as syslog protocol messages.
+// Per default RFC5424 with the KV data as STRUCTURED-DATA, but it can
+// emit legacy BSD (RFC3164) messages with the KV data in logfmt after the message.
+type syslogformatter struct {
+	out      io.Writer
+	facility syslog.Priority
+	hostname string
+	appname  string
+	sdid     string // STRUCTURED-DATA SD-ID for the event KV data
+	rfc3164  bool
+}
+
+const (
+	rfc5424TimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+	rfc3164TimeLayout = "Jan _2 15:04:05"
+
+	// The example Private Enterprise Number reserved by RFC5612
+	defaultSDID = "kv@32473"
+)
+
+// NewSyslogFormatter creates a formatting Handler writing RFC5424 syslog messages
+// to the supplied Writer. The facility defaults to LOG_USER, the hostname to os.Hostname()
+// and the APP-NAME to the program name. The name of the Logger is used as MSGID and
+// KV data is written as STRUCTURED-DATA.
+// Lines are terminated with '\n'. Use a SyslogWriter as output to do correct framing for
+// the syslog transport.
+func NewSyslogFormatter(w io.Writer, options ...HandlerOption) *syslogformatter {
+	host, _ := os.Hostname()
+	f := &syslogformatter{
+		out:      w,
+		facility: syslog.LOG_USER,
+		hostname: host,
+		appname:  filepath.Base(os.Args[0]),
+		sdid:     defaultSDID,
+	}
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+// Clone returns a clone of the current handler for tweaking and swapping in
+func (f *syslogformatter) Clone(options ...HandlerOption) CloneableHandler {
+	new := &syslogformatter{}
+	*new = *f
+	for _, option := range options {
+		option(new)
+	}
+	return new
+}
+
+// SetOutput creates a HandlerOption to set the output writer
+// This is a method wrapper to be able to have the swapper
+// call it genericly on different formatters to support SetOutput
+func (f *syslogformatter) SetOutput(w io.Writer) HandlerOption {
+	return OutputOpt(w)
+}
+
+// FacilityOpt is a syslog Formatter option to set the syslog facility. (syslog.LOG_LOCAL0 etc.)
+func FacilityOpt(facility syslog.Priority) HandlerOption {
+	return func(c CloneableHandler) {
+		if h, ok := c.(*syslogformatter); ok {
+			h.facility = facility & ^syslog.Priority(0x07)
+		}
+	}
+}
+
+// HostnameOpt is a syslog Formatter option to set the HOSTNAME field
+func HostnameOpt(hostname string) HandlerOption {
+	return func(c CloneableHandler) {
+		if h, ok := c.(*syslogformatter); ok {
+			h.hostname = hostname
+		}
+	}
+}
+
+// AppNameOpt is a syslog Formatter option to set the APP-NAME field (the TAG for RFC3164).
+// For the journal Handler it sets the SYSLOG_IDENTIFIER for events from unnamed Loggers.
+func AppNameOpt(name string) HandlerOption {
+	return func(c CloneableHandler) {
+		switch h := c.(type) {
+		case *syslogformatter:
+			h.appname = name
+		case *journalhandler:
+			h.identifier = name
+		}
+	}
+}
+
+// StructuredDataIDOpt is a syslog Formatter option to set the SD-ID used for KV data.
+// It should be on the form "name@<private enterprise number>"
+func StructuredDataIDOpt(id string) HandlerOption {
+	return func(c CloneableHandler) {
+		if h, ok := c.(*syslogformatter); ok {
+			h.sdid = id
+		}
+	}
+}
+
+// RFC3164Opt is a syslog Formatter option to select the legacy BSD syslog format.
+func RFC3164Opt(legacy bool) HandlerOption {
+	return func(c CloneableHandler) {
+		if h, ok := c.(*syslogformatter); ok {
+			h.rfc3164 = legacy
+		}
+	}
+}
+
+// Log implements the Handler interface for the syslog formatter
+func (f *syslogformatter) Log(e Event) error {
+	buf := getBuffer()
+	xbuf := buf.tmp[:0]
+
+	xbuf = append(xbuf, '<')
+	itoa(&xbuf, int(f.facility|(e.Lvl&0x07)), 1)
+	xbuf = append(xbuf, '>')
+
+	if f.rfc3164 {
+		xbuf = f.appendRFC3164(xbuf, buf, e)
+	} else {
+		xbuf = f.appendRFC5424(xbuf, e)
+	}
+
+	if l := len(xbuf); l == 0 || xbuf[l-1] != '\n' {
+		xbuf = append(xbuf, '\n')
+	}
+
+	var err error
+	if l, ok := f.out.(EvWriter); ok {
+		_, err = l.EvWrite(e, xbuf)
+	} else {
+		_, err = f.out.Write(xbuf)
+	}
+	putBuffer(buf)
+	return err
+}
+
+func (f *syslogformatter) appendRFC5424(b []byte, e Event) []byte {
+	b = append(b, "1 "...)
+	b = e.Time().AppendFormat(b, rfc5424TimeLayout)
+	b = append(b, ' ')
+	b = appendHeaderField(b, f.hostname, 255)
+	b = append(b, ' ')
+	b = appendHeaderField(b, f.appname, 48)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(pid), 10)
+	b = append(b, ' ')
+	b = appendHeaderField(b, e.Name, 32)
+	b = append(b, ' ')
+
+	if len(e.Data) == 0 {
+		b = append(b, '-')
+	} else {
+		b = append(b, '[')
+		b = append(b, f.sdid...)
+		data := withErrorCauses(flatKeyvals(e.Data))
+		for i := 0; i+1 < len(data); i += 2 {
+			name := sdParamName(data[i])
+			if name == "" {
+				continue
+			}
+			b = append(b, ' ')
+			b = append(b, name...)
+			b = append(b, `="`...)
+			b = appendSDParamValue(b, valueString(data[i+1]))
+			b = append(b, '"')
+		}
+		b = append(b, ']')
+	}
+	if e.Msg != "" {
+		b = append(b, ' ')
+		b = append(b, e.Msg...)
+	}
+	return b
+}
+
+func (f *syslogformatter) appendRFC3164(b []byte, buf *buffer, e Event) []byte {
+	b = e.Time().AppendFormat(b, rfc3164TimeLayout)
+	b = append(b, ' ')
+	b = appendHeaderField(b, f.hostname, 255)
+	b = append(b, ' ')
+	b = append(b, f.appname...)
+	b = append(b, '[')
+	b = strconv.AppendInt(b, int64(pid), 10)
+	b = append(b, "]: "...)
+	b = append(b, e.Msg...)
+	if len(e.Data) > 0 {
+		b = append(b, ' ')
+		marshalKeyvals(&buf.Buffer, e.Data...)
+		if causes := errorCauseKeyvals(e.Data); causes != nil {
+			buf.WriteByte(' ')
+			marshalKeyvals(&buf.Buffer, causes...)
+		}
+		b = append(b, buf.Buffer.Bytes()...)
+	}
+	return b
+}
+
+// Header fields are NILVALUE or 1-max chars of PRINTUSASCII
+func appendHeaderField(b []byte, s string, max int) []byte {
+	if s == "" {
+		return append(b, '-')
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 33 || c > 126 {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// SD-NAME is 1-32 chars of PRINTUSASCII except '=', SP, ']' and '"'
+func sdParamName(k interface{}) string {
+	var key string
+	switch x := k.(type) {
+	case string:
+		key = x
+	case fmt.Stringer:
+		key = safeString(x)
+	default:
+		key = fmt.Sprint(x)
+	}
+	name := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(name) < 32; i++ {
+		c := key[i]
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			continue
+		}
+		name = append(name, c)
+	}
+	return string(name)
+}
+
+// valueString renders a KV value as a plain string for formats not doing their own encoding.
+func valueString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case Lazy:
+		return x.evaluate()
+	case error:
+		return errorMessage(x)
+	case fmt.Stringer:
+		return safeString(x)
+	}
+	return fmt.Sprint(v)
+}
+
+// PARAM-VALUE must have '"', '\' and ']' escaped.
+func appendSDParamValue(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			b = append(b, '\\', c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
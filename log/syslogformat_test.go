@@ -0,0 +1,148 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogFormatter(t *testing.T) {
+	var b bytes.Buffer
+	h := log.NewSyslogFormatter(&b,
+		log.FacilityOpt(syslog.LOG_LOCAL0),
+		log.HostnameOpt("host"),
+		log.AppNameOpt("app"))
+	l := log.NewLogger(syslog.LOG_DEBUG, h)
+
+	l.WARN("hello", "key", `va"l]`, "bad key=", 1)
+
+	pattern := `^<132>1 [0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9:.]+(Z|[+-][0-9:]+) host app ` +
+		strconv.Itoa(os.Getpid()) + ` - \[kv@32473 key="va\\"l\\]" badkey="1"\] hello\n$`
+	if !regexp.MustCompile(pattern).Match(b.Bytes()) {
+		t.Errorf("Bad RFC5424 output: %q", b.String())
+	}
+
+	b.Reset()
+	l.ApplyHandlerOptions(log.RFC3164Opt(true))
+	l.ERROR("legacy", "k", "v")
+	pattern = `^<131>[A-Z][a-z]{2} [ 0-9]{2} [0-9:]{8} host app\[[0-9]+\]: legacy k=v\n$`
+	if !regexp.MustCompile(pattern).Match(b.Bytes()) {
+		t.Errorf("Bad RFC3164 output: %q", b.String())
+	}
+}
+
+func TestSyslogWriterOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			lenstr, err := r.ReadString(' ')
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(lenstr))
+			msg := make([]byte, n)
+			_, err = io.ReadFull(r, msg)
+			// Drop the connection after each message to force a reconnect.
+			conn.Close()
+			if err == nil {
+				received <- string(msg)
+			}
+		}
+	}()
+
+	w, err := log.NewSyslogWriter("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	h := log.NewSyslogFormatter(w, log.HostnameOpt("host"), log.AppNameOpt("app"))
+	l := log.NewLogger(syslog.LOG_DEBUG, h)
+
+	for _, m := range []string{"first", "second", "third"} {
+		// Let the close reach the writer
+		time.Sleep(10 * time.Millisecond)
+		l.INFO(m)
+		select {
+		case msg := <-received:
+			if !strings.HasSuffix(msg, " - - "+m) {
+				t.Errorf("Unexpected message: %q", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Message %q lost", m)
+		}
+	}
+}
+
+func TestSyslogWriterUnixStream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ln, err := net.Listen("unix", filepath.Join(dir, "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received <- line
+		}
+	}()
+
+	w, err := log.NewSyslogWriter("unix", filepath.Join(dir, "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := log.NewLogger(syslog.LOG_DEBUG, log.NewSyslogFormatter(w, log.HostnameOpt("host"), log.AppNameOpt("app")))
+
+	l.INFO("one")
+	l.INFO("two")
+	for _, m := range []string{"one", "two"} {
+		select {
+		case line := <-received:
+			if !strings.HasPrefix(line, "<14>1 ") || !strings.HasSuffix(line, " - - "+m+"\n") {
+				t.Errorf("Unexpected message: %q", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Message %q lost", m)
+		}
+	}
+}
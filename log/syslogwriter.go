@@ -0,0 +1,157 @@
+package log
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogWriter is an io.Writer sending each Write() as a syslog message to a syslog
+// daemon, doing the framing needed by the transport.
+// Datagram transports ("udp", "unixgram") send a message per datagram.
+// TCP uses RFC6587 octet-counting framing. UNIX stream sockets ("unix") get
+// newline terminated messages like local syslog daemons expect.
+// If the connection fails or the daemon has closed it, it is re-established and
+// the message written again.
+// Use it as output for the syslog Formatter.
+type SyslogWriter struct {
+	network string
+	raddr   string
+	timeout time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	framing int
+}
+
+// How messages are delimited on the connection
+const (
+	framingDatagram = iota
+	framingOctetCounting
+	framingNewline
+)
+
+// Local syslog socket paths tried when no address is given.
+var syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// ErrSyslogUnavailable is returned if no local syslog socket could be connected.
+var ErrSyslogUnavailable = errors.New("Unix syslog delivery error")
+
+// NewSyslogWriter connects to a syslog daemon at raddr using the given network.
+// If network is "" the local syslog daemon is tried on the usual UNIX socket paths.
+func NewSyslogWriter(network, raddr string) (w *SyslogWriter, err error) {
+	w = &SyslogWriter{
+		network: network,
+		raddr:   raddr,
+		timeout: 5 * time.Second,
+	}
+	w.mu.Lock()
+	err = w.connect()
+	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// connect must be called with the lock held
+func (w *SyslogWriter) connect() (err error) {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	if w.network == "" {
+		for _, network := range []string{"unixgram", "unix"} {
+			for _, path := range syslogLocalPaths {
+				var conn net.Conn
+				conn, err = net.DialTimeout(network, path, w.timeout)
+				if err == nil {
+					w.conn = conn
+					w.framing = framingDatagram
+					if network == "unix" {
+						w.framing = framingNewline
+					}
+					return
+				}
+			}
+		}
+		return ErrSyslogUnavailable
+	}
+
+	var conn net.Conn
+	conn, err = net.DialTimeout(w.network, w.raddr, w.timeout)
+	if err != nil {
+		return
+	}
+	w.conn = conn
+	switch w.network {
+	case "tcp", "tcp4", "tcp6":
+		w.framing = framingOctetCounting
+	case "unix":
+		w.framing = framingNewline
+	default:
+		w.framing = framingDatagram
+	}
+	return
+}
+
+// Write sends p as a single syslog message. Any trailing newline is removed.
+func (w *SyslogWriter) Write(p []byte) (n int, err error) {
+	msg := p
+	if l := len(msg); l > 0 && msg[l-1] == '\n' {
+		msg = msg[:l-1]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil && w.framing != framingDatagram && peerClosed(w.conn) {
+		// Writing would succeed, but the message would be lost.
+		w.conn.Close()
+		w.conn = nil
+	}
+	if w.conn != nil {
+		if err = w.write(msg); err == nil {
+			return len(p), nil
+		}
+	}
+	// (re)connect and retry once
+	if err = w.connect(); err != nil {
+		return 0, err
+	}
+	if err = w.write(msg); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *SyslogWriter) write(msg []byte) (err error) {
+	switch w.framing {
+	case framingOctetCounting:
+		var hdr [24]byte
+		h := strconv.AppendInt(hdr[:0], int64(len(msg)), 10)
+		h = append(h, ' ')
+		_, err = (&net.Buffers{h, msg}).WriteTo(w.conn)
+	case framingNewline:
+		_, err = (&net.Buffers{msg, []byte{'\n'}}).WriteTo(w.conn)
+	default:
+		_, err = w.conn.Write(msg)
+	}
+	return
+}
+
+// Close closes the connection to the syslog daemon. A later Write will reconnect.
+func (w *SyslogWriter) Close() (err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		err = w.conn.Close()
+		w.conn = nil
+	}
+	return
+}
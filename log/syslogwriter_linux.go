@@ -0,0 +1,32 @@
+package log
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerClosed reports whether the other end has closed the stream connection.
+// A syslog daemon doesn't send anything, so a readable connection is at EOF (or reset).
+func peerClosed(conn net.Conn) (closed bool) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return
+	}
+	raw.Read(func(fd uintptr) bool {
+		var b [1]byte
+		n, _, err := syscall.Recvfrom(int(fd), b[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		switch {
+		case err == syscall.EAGAIN || err == syscall.EINTR:
+		case err != nil:
+			closed = true
+		case n == 0:
+			closed = true
+		}
+		return true // don't wait
+	})
+	return
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package log
+
+import (
+	"net"
+)
+
+// peerClosed can't tell without a non-blocking peek. The write failing will tell.
+func peerClosed(conn net.Conn) bool {
+	return false
+}
@@ -0,0 +1,217 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// templateformatter writes events as lines following a pattern
+type templateformatter struct {
+	flag       int // LUTC, Lcolor and Lshortfile
+	out        io.Writer
+	timelayout string
+	pfxarr     *[8]string // strings for %level
+	segments   []templateSegment
+}
+
+// A piece of the parsed template. Either a literal or a field verb.
+type templateSegment struct {
+	verb    string
+	literal string
+}
+
+var templateLevels = [8]string{"EMERG", "ALERT", "CRIT", "ERROR", "WARN", "NOTICE", "INFO", "DEBUG"}
+
+var templateVerbs = map[string]bool{
+	"time": true, "level": true, "name": true, "msg": true,
+	"kv": true, "file": true, "line": true, "pid": true,
+}
+
+// DefaultTemplate is the pattern used by NewTemplateFormatter if given an empty pattern.
+const DefaultTemplate = "%time %level [%name] %msg %kv"
+
+// NewTemplateFormatter creates a formatting Handler writing events as lines following
+// a pattern like DefaultTemplate. The pattern can contain the verbs:
+//
+//	%time   The event timestamp formatted by the layout set by TimeFormatOpt (default "2006/01/02 15:04:05")
+//	%level  The level name (can be changed by LevelPrefixOpt)
+//	%name   The Logger name
+//	%msg    The message
+//	%kv     The KV data in logfmt - with the causes of errors
+//	%file   The source file (when the Logger does code info)
+//	%line   The source line
+//	%pid    The process ID
+//	%%      A '%'
+//
+// Anything else is written as is. Trailing whitespace is removed and a newline added.
+// Any stack trace follows on the next lines, like for NewStdFormatter.
+// The flags LUTC, Lcolor (coloring the level) and Lshortfile (for %file) are supported.
+func NewTemplateFormatter(w io.Writer, pattern string, options ...HandlerOption) *templateformatter {
+	f := &templateformatter{
+		out:        w,
+		timelayout: "2006/01/02 15:04:05",
+		pfxarr:     &templateLevels,
+	}
+	TemplateOpt(pattern)(f)
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+// TemplateOpt is a template Formatter option to set the pattern
+func TemplateOpt(pattern string) HandlerOption {
+	return func(c CloneableHandler) {
+		if h, ok := c.(*templateformatter); ok {
+			if pattern == "" {
+				pattern = DefaultTemplate
+			}
+			h.segments = parseTemplate(pattern)
+		}
+	}
+}
+
+func parseTemplate(pattern string) (segments []templateSegment) {
+	var literal []byte
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i+1 == len(pattern) {
+			literal = append(literal, c)
+			continue
+		}
+		if pattern[i+1] == '%' {
+			literal = append(literal, '%')
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(pattern) && pattern[j] >= 'a' && pattern[j] <= 'z' {
+			j++
+		}
+		verb := pattern[i+1 : j]
+		if !templateVerbs[verb] {
+			literal = append(literal, c)
+			continue
+		}
+		if len(literal) > 0 {
+			segments = append(segments, templateSegment{literal: string(literal)})
+			literal = literal[:0]
+		}
+		segments = append(segments, templateSegment{verb: verb})
+		i = j - 1
+	}
+	if len(literal) > 0 {
+		segments = append(segments, templateSegment{literal: string(literal)})
+	}
+	return
+}
+
+// Clone returns a clone of the current handler for tweaking and swapping in
+func (f *templateformatter) Clone(options ...HandlerOption) CloneableHandler {
+	new := &templateformatter{}
+	*new = *f
+	for _, option := range options {
+		option(new)
+	}
+	return new
+}
+
+// Flags returns the formatter flags
+func (f *templateformatter) Flags() int {
+	return f.flag
+}
+
+// Prefix is not supported by the template formatter, but needed for StdFormatter
+func (f *templateformatter) Prefix() string {
+	return ""
+}
+
+// SetFlags creates a HandlerOption to set flags.
+func (f *templateformatter) SetFlags(flags int) HandlerOption {
+	return FlagsOpt(flags)
+}
+
+// SetOutput creates a HandlerOption to set the output writer
+func (f *templateformatter) SetOutput(w io.Writer) HandlerOption {
+	return OutputOpt(w)
+}
+
+// AutoColoring creates a HandlerOption coloring the level, if the output is a terminal.
+func (f *templateformatter) AutoColoring() HandlerOption {
+	return func(c CloneableHandler) {
+		if o, ok := c.(*templateformatter); ok {
+			o.flag = colorFlag(o.flag, o.out)
+		}
+	}
+}
+
+// Log implements the Handler interface
+func (f *templateformatter) Log(e Event) error {
+	buf := getBuffer()
+
+	for _, s := range f.segments {
+		switch s.verb {
+		case "":
+			buf.WriteString(s.literal)
+		case "time":
+			t := e.Time()
+			if f.flag&LUTC != 0 {
+				t = t.UTC()
+			}
+			buf.WriteString(t.Format(f.timelayout))
+		case "level":
+			if f.flag&Lcolor != 0 {
+				buf.WriteString("\x1b[" + levelColors[e.Lvl&0x07] + "m" + (*f.pfxarr)[e.Lvl&0x07] + "\x1b[0m")
+			} else {
+				buf.WriteString((*f.pfxarr)[e.Lvl&0x07])
+			}
+		case "name":
+			buf.WriteString(e.Name)
+		case "msg":
+			buf.WriteString(strings.TrimSuffix(e.Msg, "\n"))
+		case "kv":
+			marshalKeyvals(&buf.Buffer, e.Data...)
+			if causes := errorCauseKeyvals(e.Data); causes != nil {
+				if len(e.Data) > 0 {
+					buf.WriteByte(' ')
+				}
+				marshalKeyvals(&buf.Buffer, causes...)
+			}
+		case "file":
+			file := "???"
+			if e.fok {
+				file = e.file
+				if f.flag&Lshortfile != 0 {
+					file = filepath.Base(file)
+				}
+			}
+			buf.WriteString(file)
+		case "line":
+			xbuf := buf.tmp[:0]
+			itoa(&xbuf, e.line, -1)
+			buf.Write(xbuf)
+		case "pid":
+			xbuf := buf.tmp[:0]
+			itoa(&xbuf, pid, -1)
+			buf.Write(xbuf)
+		}
+	}
+
+	buf.Truncate(len(bytes.TrimRight(buf.Bytes(), " \t")))
+	buf.WriteByte('\n')
+	if len(e.stack) > 0 {
+		buf.Write(appendStackTrace(buf.tmp[:0], e))
+	}
+	line := buf.Bytes()
+
+	var err error
+	if l, ok := f.out.(EvWriter); ok {
+		_, err = l.EvWrite(e, line)
+	} else {
+		_, err = f.out.Write(line)
+	}
+	putBuffer(buf)
+	return err
+}
@@ -0,0 +1,35 @@
+package log_test
+
+import (
+	"bytes"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"regexp"
+	"testing"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	var b bytes.Buffer
+	h := log.NewTemplateFormatter(&b, "%level [%name] 100%% %msg %unknown %kv")
+	l := log.GetLogger("template/test")
+	l.SetHandler(h)
+	l.SetLevel(syslog.LOG_DEBUG)
+
+	l.ERROR("hello", "k", "v")
+	l.INFO("no kv")
+	expected := "ERROR [template/test] 100% hello %unknown k=v\nINFO [template/test] 100% no kv %unknown\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+
+	b.Reset()
+	l.DoCodeInfo(true)
+	l.ApplyHandlerOptions(log.TemplateOpt("%file:%line %msg"), log.FlagsOpt(log.Lshortfile))
+	l.INFO("code")
+	if !regexp.MustCompile(`^template_test.go:\d+ code\n$`).MatchString(b.String()) {
+		t.Errorf("Unexpected output: %q", b.String())
+	}
+	if l.Flags() != log.Lshortfile {
+		t.Error("Flags not supported")
+	}
+}
@@ -0,0 +1,141 @@
+package metric
+
+import (
+	"github.com/One-com/gone/metric/num64"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Client side aggregation of Timer and Histogram events.
+
+// The aggregate histogram is log-linear (HDR style): Every power of 2 is divided in
+// aggSubBuckets linear buckets, giving a relative error of at most 1/(2*aggSubBuckets).
+const (
+	aggSubBits    = 4
+	aggSubBuckets = 1 << aggSubBits
+	aggBuckets    = (64 - aggSubBits + 1) * aggSubBuckets
+)
+
+// DefaultPercentiles are the percentiles calculated by Aggregate() if none are given.
+var DefaultPercentiles = []float64{50, 90, 99}
+
+// aggregate accumulates events between flushes in a fixed size histogram.
+type aggregate struct {
+	percentiles []float64
+	names       []string // ".p50" ...
+
+	count    uint64
+	sum      float64
+	min, max int64
+	pos      [aggBuckets]uint64
+	neg      *[aggBuckets]uint64 // negative values by absolute value. Allocated when needed
+}
+
+// Meters which can aggregate events client side with the Aggregate() MOption
+type aggregatable interface {
+	setAggregate(percentiles []float64)
+}
+
+func newAggregate(percentiles []float64) *aggregate {
+	a := &aggregate{}
+	for _, p := range percentiles {
+		if !(p > 0 && p <= 100) {
+			continue
+		}
+		a.percentiles = append(a.percentiles, p)
+		a.names = append(a.names, ".p"+strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", 1))
+	}
+	return a
+}
+
+// aggIndex finds the bucket of v
+func aggIndex(v uint64) int {
+	if v < aggSubBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1 // >= aggSubBits
+	sub := (v >> uint(exp-aggSubBits)) & (aggSubBuckets - 1)
+	return (exp-aggSubBits+1)*aggSubBuckets + int(sub)
+}
+
+// aggValue returns the middle of the bucket idx
+func aggValue(idx int) uint64 {
+	if idx < aggSubBuckets {
+		return uint64(idx)
+	}
+	shift := uint(idx/aggSubBuckets - 1)
+	low := uint64(aggSubBuckets+idx%aggSubBuckets) << shift
+	return low + (uint64(1)<<shift-1)/2
+}
+
+func (a *aggregate) add(v int64) {
+	if a.count == 0 || v < a.min {
+		a.min = v
+	}
+	if a.count == 0 || v > a.max {
+		a.max = v
+	}
+	a.count++
+	a.sum += float64(v)
+	if v >= 0 {
+		a.pos[aggIndex(uint64(v))]++
+		return
+	}
+	if a.neg == nil {
+		a.neg = new([aggBuckets]uint64)
+	}
+	a.neg[aggIndex(uint64(-v))]++
+}
+
+// percentile estimates the p'th percentile of the values added.
+func (a *aggregate) percentile(p float64) int64 {
+	rank := uint64(math.Ceil(p / 100 * float64(a.count)))
+	if rank == 0 {
+		rank = 1
+	}
+	var n uint64
+	v, found := a.max, false
+	if a.neg != nil {
+		for i := aggBuckets - 1; i >= 0 && !found; i-- {
+			if n += a.neg[i]; n >= rank {
+				v, found = -int64(aggValue(i)), true
+			}
+		}
+	}
+	for i := 0; i < aggBuckets && !found; i++ {
+		if n += a.pos[i]; n >= rank {
+			v, found = int64(aggValue(i)), true
+		}
+	}
+	if v < a.min {
+		v = a.min
+	}
+	if v > a.max {
+		v = a.max
+	}
+	return v
+}
+
+// flush records the count, min, max, mean and percentiles as gauges and resets the aggregate.
+// If there were no events, only the count is recorded.
+func (a *aggregate) flush(s Sink, name string, tags []Tag) {
+	recordNumeric64(s, MeterGauge, name+".count", tags, num64.FromUint64(a.count))
+	if a.count == 0 {
+		return
+	}
+	recordNumeric64(s, MeterGauge, name+".min", tags, num64.FromInt64(a.min))
+	recordNumeric64(s, MeterGauge, name+".max", tags, num64.FromInt64(a.max))
+	recordNumeric64(s, MeterGauge, name+".mean", tags, num64.FromFloat64(a.sum/float64(a.count)))
+	for i, p := range a.percentiles {
+		recordNumeric64(s, MeterGauge, name+a.names[i], tags, num64.FromInt64(a.percentile(p)))
+	}
+
+	a.count = 0
+	a.sum = 0
+	a.pos = [aggBuckets]uint64{}
+	if a.neg != nil {
+		*a.neg = [aggBuckets]uint64{}
+	}
+}
@@ -0,0 +1,91 @@
+package metric_test
+
+import (
+	"bytes"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/sink/statsd"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parse statsd gauge lines to a map
+func gauges(t *testing.T, out string) map[string]float64 {
+	m := make(map[string]float64)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.FieldsFunc(line, func(r rune) bool { return r == ':' || r == '|' })
+		if len(parts) != 3 || parts[2] != "g" {
+			t.Fatalf("Not a gauge: %q", line)
+		}
+		if _, ok := m[parts[0]]; ok {
+			t.Errorf("%s sent twice", parts[0])
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m[parts[0]] = v
+	}
+	return m
+}
+
+func TestAggregate(t *testing.T) {
+	var buffer = &bytes.Buffer{}
+	sink, err := statsd.New(statsd.Buffer(512), statsd.Output(buffer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := metric.NewClient(sink)
+	timer := client.RegisterTimer("timer", metric.Aggregate())
+	histo := client.RegisterHistogram("histo", metric.Aggregate(50, 99.9))
+
+	// More events than the event buffer holds
+	for i := 1; i <= 10000; i++ {
+		timer.Sample(time.Duration(i) * time.Millisecond)
+	}
+	histo.Sample(-100)
+	histo.Sample(200)
+	client.Flush()
+
+	got := gauges(t, buffer.String())
+	expected := map[string]float64{
+		"timer.count": 10000,
+		"timer.min":   1,
+		"timer.max":   10000,
+		"timer.mean":  5000.5,
+		"timer.p50":   5000,
+		"timer.p90":   9000,
+		"timer.p99":   9900,
+		"histo.count": 2,
+		"histo.min":   -100,
+		"histo.max":   200,
+		"histo.mean":  50,
+		"histo.p50":   -100,
+		"histo.p99_9": 200,
+	}
+	if len(got) != len(expected) {
+		t.Errorf("Got %v", got)
+	}
+	for name, v := range expected {
+		g, ok := got[name]
+		if !ok {
+			t.Errorf("%s not sent", name)
+			continue
+		}
+		if math.Abs(g-v) > math.Abs(v)*0.035 {
+			t.Errorf("%s: %v, expected about %v", name, g, v)
+		}
+	}
+
+	// Only the count is sent if there were no events
+	buffer.Reset()
+	client.Flush()
+	if out := buffer.String(); out != "timer.count:0|g\nhisto.count:0|g\n" {
+		t.Errorf("Wrong output %q", out)
+	}
+}
@@ -188,6 +188,18 @@ func (c *Client) Register(m Meter, opts ...MOption) {
 		o(conf)
 	}
 
+	if tags, ok := conf.cfg["tags"].([]Tag); ok {
+		if t, ok := m.(taggable); ok {
+			t.setTags(tags)
+		}
+	}
+
+	if p, ok := conf.cfg["aggregate"].([]float64); ok {
+		if a, ok := m.(aggregatable); ok {
+			a.setAggregate(p)
+		}
+	}
+
 	if fi, ok := conf.cfg["flushInterval"]; ok {
 		flush = fi.(time.Duration)
 		if f, ok = c.flushers[flush]; !ok {
@@ -295,72 +307,82 @@ func RegisterHistogram(name string, opts ...MOption) Histogram {
 //--------------------------------------------------------------
 
 // AdhocCount creates an ad-hoc counter metric event.
-// If flush is true, the sink will be instructed to flush data immediately
-func (c *Client) AdhocCount(name string, val int, flush bool) {
-	c.defaultFlusher.RecordNumeric64(MeterCounter, name, num64.FromInt64(int64(val)), flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func (c *Client) AdhocCount(name string, val int, flush bool, tags ...Tag) {
+	c.defaultFlusher.RecordNumeric64(MeterCounter, name, tags, num64.FromInt64(int64(val)), flush)
 }
 
 // AdhocGauge creates an ad-hoc gauge metric event.
-// If flush is true, the sink will be instructed to flush data immediately
-func (c *Client) AdhocGauge(name string, val uint64, flush bool) {
-	c.defaultFlusher.RecordNumeric64(MeterGauge, name, num64.FromUint64(val), flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func (c *Client) AdhocGauge(name string, val uint64, flush bool, tags ...Tag) {
+	c.defaultFlusher.RecordNumeric64(MeterGauge, name, tags, num64.FromUint64(val), flush)
 }
 
 // AdhocTime creates an ad-hoc timer metric event.
-// If flush is true, the sink will be instructed to flush data immediately
-func (c *Client) AdhocTime(name string, d time.Duration, flush bool) {
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func (c *Client) AdhocTime(name string, d time.Duration, flush bool, tags ...Tag) {
 	val := d.Nanoseconds() / int64(1000000)
-	c.defaultFlusher.RecordNumeric64(MeterTimer, name, num64.FromInt64(int64(val)), flush)
+	c.defaultFlusher.RecordNumeric64(MeterTimer, name, tags, num64.FromInt64(int64(val)), flush)
 }
 
 // AdhocSample creates an ad-hoc histogram metric event.
-// If flush is true, the sink will be instructed to flush data immediately
-func (c *Client) AdhocSample(name string, val int64, flush bool) {
-	c.defaultFlusher.RecordNumeric64(MeterHistogram, name, num64.FromInt64(int64(val)), flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func (c *Client) AdhocSample(name string, val int64, flush bool, tags ...Tag) {
+	c.defaultFlusher.RecordNumeric64(MeterHistogram, name, tags, num64.FromInt64(int64(val)), flush)
 }
 
 // AdhocSetMember creates an ad-hoc set membership event.
-// If flush is true, the sink will be instructed to flush data immediately
-func (c *Client) AdhocSetMember(name string, member string, flush bool) {
-	c.defaultFlusher.Record(MeterSet, name, member, flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func (c *Client) AdhocSetMember(name string, member string, flush bool, tags ...Tag) {
+	c.defaultFlusher.Record(MeterSet, name, tags, member, flush)
 }
 
 // Mark - send a ad-hoc zero histogram event immediately to allow the server side to indicate a unique event happened. This equivalent to calling Sample(name, 0, true) and can be used as a poor mans way to make qualitative events to be marked in the overall view of metrics. Like "process restart". Graphical views might allow you to draw these as special marks. For some sinks (like statsd) there's not dedicated way to send such events.
 // Mark is equivalent to AdhocSample(name, 0, true)
 func (c *Client) Mark(name string) {
-	c.defaultFlusher.RecordNumeric64(MeterHistogram, name, num64.FromInt64(int64(0)), true)
+	c.defaultFlusher.RecordNumeric64(MeterHistogram, name, nil, num64.FromInt64(int64(0)), true)
 }
 
 //--------------------------------------------------------------
 
 // AdhocCount creates an ad-hoc counter metric event at the default client.
-// If flush is true, the sink will be instructed to flush data immediately
-func AdhocCount(name string, val int, flush bool) {
-	defaultClient.AdhocCount(name, val, flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func AdhocCount(name string, val int, flush bool, tags ...Tag) {
+	defaultClient.AdhocCount(name, val, flush, tags...)
 }
 
 // AdhocGauge creates an ad-hoc gauge metric event at the default client.
-// If flush is true, the sink will be instructed to flush data immediately
-func AdhocGauge(name string, val uint64, flush bool) {
-	defaultClient.AdhocGauge(name, val, flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func AdhocGauge(name string, val uint64, flush bool, tags ...Tag) {
+	defaultClient.AdhocGauge(name, val, flush, tags...)
 }
 
 // AdhocTime creates an ad-hoc timer metric event at the default client.
-// If flush is true, the sink will be instructed to flush data immediately
-func AdhocTime(name string, d time.Duration, flush bool) {
-	defaultClient.AdhocTime(name, d, flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func AdhocTime(name string, d time.Duration, flush bool, tags ...Tag) {
+	defaultClient.AdhocTime(name, d, flush, tags...)
 }
 
 // AdhocSample creates an ad-hoc histogram metric event at the default client.
-// If flush is true, the sink will be instructed to flush data immediately
-func AdhocSample(name string, val int64, flush bool) {
-	defaultClient.AdhocSample(name, val, flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func AdhocSample(name string, val int64, flush bool, tags ...Tag) {
+	defaultClient.AdhocSample(name, val, flush, tags...)
 }
 
 // AdhocSetMember generates an ad-hoc set membership event with the default client.
-// If flush is true, the sink will be instructed to flush data immediately
-func AdhocSetMember(name string, member string, flush bool) {
-	defaultClient.AdhocSetMember(name, member, flush)
+// If flush is true, the sink will be instructed to flush data immediately.
+// Tags are passed on to Sinks implementing TaggedSink.
+func AdhocSetMember(name string, member string, flush bool, tags ...Tag) {
+	defaultClient.AdhocSetMember(name, member, flush, tags...)
 }
 
 // Mark - send a ad-hoc zero histogram event at the default client. - see Client.Mark()
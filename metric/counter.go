@@ -9,6 +9,7 @@ import (
 // time its flushed - and thus being server-side maintained.
 type Counter struct {
 	name string
+	tags []Tag
 	val  int64
 }
 
@@ -28,10 +29,14 @@ func (c *Counter) FlushReading(s Sink) {
 	val := atomic.SwapInt64(&c.val, 0)
 	if val != 0 {
 		n := num64.FromInt64(int64(val))
-		s.RecordNumeric64(MeterCounter, c.name, n)
+		recordNumeric64(s, MeterCounter, c.name, c.tags, n)
 	}
 }
 
+func (c *Counter) setTags(tags []Tag) {
+	c.tags = tags
+}
+
 // Name returns the name of the counter
 func (c *Counter) Name() string {
 	return c.name
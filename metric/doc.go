@@ -1,5 +1,5 @@
 /*
-Package metric is a generic metric package for the standard metric types gauges/counters/timers/histograms. It ships with a statsd sink implementation, but can be extended with new sinks.
+Package metric is a generic metric package for the standard metric types gauges/counters/timers/histograms. It ships with a statsd sink and a Prometheus sink implementation, but can be extended with new sinks.
 
 This implementation is aimed at being as fast as possible to not discourage metrics on values in hotpaths just because of locking overhead. This requires some client side buffering (and flusher go-routines) and, especially for the timer/histogram event type, a relatively large data structure to create a ring-buffer with mostly lock-free writes. (it uses condition variables for flushing).
 This design is for the use case where you have a lot of timer/histogram metric events going to a few buckets.
@@ -22,7 +22,7 @@ type event struct {
 	cv  *sync.Cond
 }
 
-type dequeueFunc func(f Sink, val uint64)
+type dequeueFunc func(f Sink, tags []Tag, val uint64)
 
 // A generic stream of values which all have to be propagated to the sink.
 type eventStream struct {
@@ -35,6 +35,8 @@ type eventStream struct {
 	dequeue dequeueFunc
 
 	name string
+	tags []Tag
+	agg  *aggregate // if aggregating client side
 }
 
 func newEventStream(name string, dqf dequeueFunc) *eventStream {
@@ -53,8 +55,25 @@ func (e *eventStream) setFlusher(f *flusher) {
 	e.flusher = f
 }
 
+func (e *eventStream) setTags(tags []Tag) {
+	e.tags = tags
+}
+
+func (e *eventStream) setAggregate(percentiles []float64) {
+	e.agg = newAggregate(percentiles)
+}
+
 // FlushReading - flush as much as possible.
+// An aggregating stream sends the aggregate of the events read since the last FlushReading.
 func (e *eventStream) FlushReading(s Sink) {
+	e.read(s)
+	if e.agg != nil {
+		e.agg.flush(s, e.name, e.tags)
+	}
+}
+
+// read as much as possible - sending the events to the Sink, or adding them to the aggregate.
+func (e *eventStream) read(s Sink) {
 
 	var idx uint64
 
@@ -67,7 +86,11 @@ func (e *eventStream) FlushReading(s Sink) {
 		mark := atomic.LoadUint64(&(e.slots[idx].seq))
 		// either tagged with its slot, or +1 for waited on
 		if mark == ridx || mark == ridx+1 {
-			e.dequeue(s, e.slots[idx].val)
+			if e.agg != nil {
+				e.agg.add(int64(e.slots[idx].val))
+			} else {
+				e.dequeue(s, e.tags, e.slots[idx].val)
+			}
 			ridx++
 		} else {
 			// we've reached a not yet written slot
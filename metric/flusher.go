@@ -167,9 +167,14 @@ LOOP:
 }
 
 // flush a single meter. Sync with the Flusher mutex
+// Aggregating meters only read their events to make room. The aggregate is sent by Flush()
 func (f *flusher) FlushMeter(m Meter) {
 	f.mu.Lock()
-	m.FlushReading(f.sink)
+	if e, ok := m.(*eventStream); ok && e.agg != nil {
+		e.read(f.sink)
+	} else {
+		m.FlushReading(f.sink)
+	}
 	f.mu.Unlock()
 }
 
@@ -221,9 +226,9 @@ func (f *flusher) deregister(m Meter) error {
 
 // Record records a value directly at the sink of this flusher.
 // optionally flusing the sink.
-func (f *flusher) Record(mtype int, name string, value interface{}, flush bool) {
+func (f *flusher) Record(mtype int, name string, tags []Tag, value interface{}, flush bool) {
 	f.mu.Lock()
-	f.sink.Record(mtype, name, value)
+	record(f.sink, mtype, name, tags, value)
 	if flush {
 		f.sink.Flush()
 	}
@@ -232,9 +237,9 @@ func (f *flusher) Record(mtype int, name string, value interface{}, flush bool)
 
 // RecordNumeric64 records a value directly at the sink of this flusher.
 // optionally flusing the sink.
-func (f *flusher) RecordNumeric64(mtype int, name string, value num64.Numeric64, flush bool) {
+func (f *flusher) RecordNumeric64(mtype int, name string, tags []Tag, value num64.Numeric64, flush bool) {
 	f.mu.Lock()
-	f.sink.RecordNumeric64(mtype, name, value)
+	recordNumeric64(f.sink, mtype, name, tags, value)
 	if flush {
 		f.sink.Flush()
 	}
@@ -13,12 +13,14 @@ import (
 // GaugeUint64 is the default gauge type using a uint64
 type GaugeUint64 struct {
 	name string
+	tags []Tag
 	val  uint64
 }
 
 // GaugeInt64 is a gauge using an int64 - meaning it can be decremented to negaive values
 type GaugeInt64 struct {
 	name string
+	tags []Tag
 	val  int64
 }
 
@@ -26,6 +28,7 @@ type GaugeInt64 struct {
 // to implement FlushReading() fast (saving an interface allocation)
 type GaugeFloat64 struct {
 	name string
+	tags []Tag
 	val  uint64
 }
 
@@ -44,7 +47,11 @@ func NewGaugeUint64(name string) *GaugeUint64 {
 func (g *GaugeUint64) FlushReading(s Sink) {
 	val := atomic.LoadUint64(&g.val)
 	n := num64.FromUint64(val)
-	s.RecordNumeric64(MeterGauge, g.name, n)
+	recordNumeric64(s, MeterGauge, g.name, g.tags, n)
+}
+
+func (g *GaugeUint64) setTags(tags []Tag) {
+	g.tags = tags
 }
 
 // Name to implement Meter interface
@@ -82,7 +89,11 @@ func NewGaugeInt64(name string) *GaugeInt64 {
 func (g *GaugeInt64) FlushReading(s Sink) {
 	val := atomic.LoadInt64(&g.val)
 	n := num64.FromInt64(val)
-	s.RecordNumeric64(MeterGauge, g.name, n)
+	recordNumeric64(s, MeterGauge, g.name, g.tags, n)
+}
+
+func (g *GaugeInt64) setTags(tags []Tag) {
+	g.tags = tags
 }
 
 // Name returns the name of the gauge.
@@ -116,6 +127,10 @@ func NewGaugeFloat64(name string) *GaugeFloat64 {
 	return g
 }
 
+func (g *GaugeFloat64) setTags(tags []Tag) {
+	g.tags = tags
+}
+
 // Name returns the name of the gauge
 func (g *GaugeFloat64) Name() string {
 	return g.name
@@ -136,5 +151,5 @@ func (g *GaugeFloat64) Value() float64 {
 func (g *GaugeFloat64) FlushReading(s Sink) {
 	val := atomic.LoadUint64(&g.val)
 	n := num64.Float64FromUint64(val)
-	s.RecordNumeric64(MeterGauge, g.name, n)
+	recordNumeric64(s, MeterGauge, g.name, g.tags, n)
 }
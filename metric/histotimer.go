@@ -13,9 +13,9 @@ type Histogram struct {
 // NewHistogram creates a new persistent metric object measuring arbitrary sample values
 // by allocating a client side FIFO buffer for recording and flushing measurements
 func NewHistogram(name string) Histogram {
-	dequeuef := func(f Sink, val uint64) {
+	dequeuef := func(f Sink, tags []Tag, val uint64) {
 		n := num64.FromInt64(int64(val))
-		f.RecordNumeric64(MeterHistogram, name, n)
+		recordNumeric64(f, MeterHistogram, name, tags, n)
 	}
 	t := newEventStream(name, dequeuef)
 	return Histogram{t}
@@ -36,9 +36,9 @@ type Timer struct {
 // NewTimer creates a new persistent metric object measuring timing values.
 // by allocating a client side FIFO buffer for recording and flushing measurements
 func NewTimer(name string) Timer {
-	dequeuef := func(f Sink, val uint64) {
+	dequeuef := func(f Sink, tags []Tag, val uint64) {
 		n := num64.FromUint64(val)
-		f.RecordNumeric64(MeterTimer, name, n)
+		recordNumeric64(f, MeterTimer, name, tags, n)
 	}
 	t := newEventStream(name, dequeuef)
 	return Timer{t}
@@ -0,0 +1,166 @@
+// Package logbridge counts log events from gone/log as gone/metric Counters.
+//
+// The Handler is meant to sit in a log.MultiHandler next to the real formatter:
+//
+//	counts := logbridge.New(metric.Default())
+//	log.Default().SetHandler(log.MultiHandler(formatter, counts))
+//
+// Every event increments a counter named by the level and logger name, like "log.error.http.server"
+// for an ERROR event from the Logger "http/server".
+package logbridge
+
+import (
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"github.com/One-com/gone/metric"
+	"strings"
+	"sync"
+)
+
+// OverflowName is the last name element used for message key counters when the limit of
+// distinct values has been reached.
+const OverflowName = "other"
+
+// Handler is a log.Handler incrementing metric Counters for log events.
+type Handler struct {
+	client *metric.Client
+	prefix string
+	opts   []metric.MOption
+
+	msgkey string
+	limit  int
+
+	mu       sync.RWMutex
+	counters map[counterKey]*metric.Counter
+	values   map[string]bool // distinct message key values seen
+}
+
+type counterKey struct {
+	lvl   syslog.Priority
+	name  string
+	value string
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// Prefix sets the first element of the counter names. Default is "log".
+func Prefix(pfx string) Option {
+	return func(h *Handler) {
+		h.prefix = pfx
+	}
+}
+
+// MetricOptions sets the options used when registering counters with the Client
+// (like metric.FlushInterval)
+func MetricOptions(opts ...metric.MOption) Option {
+	return func(h *Handler) {
+		h.opts = opts
+	}
+}
+
+// MessageKey additionally counts events having KV data for key per value, like "log.error.http.server.timeout"
+// for an event logged with "event", "timeout" and key "event".
+// To bound the number of counters, at most limit distinct values are counted by name.
+// Further values are counted as OverflowName.
+func MessageKey(key string, limit int) Option {
+	return func(h *Handler) {
+		h.msgkey = key
+		h.limit = limit
+	}
+}
+
+// New creates a Handler registering counters on client.
+func New(client *metric.Client, options ...Option) *Handler {
+	h := &Handler{
+		client:   client,
+		prefix:   "log",
+		counters: make(map[counterKey]*metric.Counter),
+		values:   make(map[string]bool),
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// Log implements log.Handler. It never returns an error.
+func (h *Handler) Log(e log.Event) error {
+	lvl := e.Lvl & 0x07
+	h.counter(counterKey{lvl: lvl, name: e.Name}).Inc(1)
+
+	if h.msgkey != "" {
+		for i := 0; i+1 < len(e.Data); i += 2 {
+			if k, ok := e.Data[i].(string); ok && k == h.msgkey {
+				if v, ok := e.Data[i+1].(string); ok && v != "" {
+					h.counter(counterKey{lvl: lvl, name: e.Name, value: h.bound(v)}).Inc(1)
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Map a message key value to OverflowName if the limit of distinct values is reached.
+func (h *Handler) bound(v string) string {
+	h.mu.RLock()
+	seen := h.values[v]
+	h.mu.RUnlock()
+	if seen {
+		return v
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.values[v] {
+		return v
+	}
+	if len(h.values) >= h.limit {
+		return OverflowName
+	}
+	h.values[v] = true
+	return v
+}
+
+func (h *Handler) counter(key counterKey) *metric.Counter {
+	h.mu.RLock()
+	c, ok := h.counters[key]
+	h.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c, ok = h.counters[key]; !ok {
+		c = h.client.RegisterCounter(h.metricName(key), h.opts...)
+		h.counters[key] = c
+	}
+	return c
+}
+
+func (h *Handler) metricName(key counterKey) string {
+	elems := []string{log.LevelName(key.lvl)}
+	if key.name != "" {
+		elems = append(elems, strings.Split(key.name, "/")...)
+	}
+	if key.value != "" {
+		elems = append(elems, key.value)
+	}
+	for i := range elems {
+		elems[i] = strings.Map(sanitize, elems[i])
+	}
+	if h.prefix != "" {
+		elems = append([]string{h.prefix}, elems...)
+	}
+	return strings.Join(elems, ".")
+}
+
+// Replace characters not safe in metric names
+func sanitize(r rune) rune {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		return r
+	}
+	return '_'
+}
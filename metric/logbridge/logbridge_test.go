@@ -0,0 +1,59 @@
+package logbridge_test
+
+import (
+	"bytes"
+	"github.com/One-com/gone/log"
+	"github.com/One-com/gone/log/syslog"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/logbridge"
+	"github.com/One-com/gone/metric/sink/statsd"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	var b bytes.Buffer
+	sink, err := statsd.New(statsd.Buffer(512), statsd.Output(&b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := metric.NewClient(sink)
+
+	var out bytes.Buffer
+	counts := logbridge.New(client, logbridge.MessageKey("event", 2))
+	l := log.NewLogger(syslog.LOG_DEBUG, log.MultiHandler(log.NewStdFormatter(&out, "", 0), counts))
+	l = l.With("svc", "x")
+
+	server := log.GetLogger("http/server")
+	server.SetLevel(syslog.LOG_DEBUG)
+	server.SetHandler(counts)
+
+	l.ERROR("failed")
+	l.ERROR("failed again", "event", "timeout")
+	server.ERROR("request failed", "event", "timeout")
+	server.ERROR("request failed", "event", "reset")
+	server.ERROR("request failed", "event", "refused")
+	server.WARN("slow", "event", "slow path")
+	client.Flush()
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	sort.Strings(lines)
+	expected := []string{
+		"log.error.http.server.other:1|c",
+		"log.error.http.server.reset:1|c",
+		"log.error.http.server.timeout:1|c",
+		"log.error.http.server:3|c",
+		"log.error.timeout:1|c",
+		"log.error:2|c",
+		"log.warning.http.server:1|c",
+		"log.warning.http.server.other:1|c",
+	}
+	sort.Strings(expected)
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+	if out.String() != "failed svc=x\nfailed again svc=x event=timeout\n" {
+		t.Errorf("Formatter output missing: %q", out.String())
+	}
+}
@@ -20,3 +20,28 @@ func FlushInterval(d time.Duration) MOption {
 		m.cfg["flushInterval"] = d
 	})
 }
+
+// Tags returns a configuration option giving a Meter tags.
+// Provide this to Register* for Meters created by this package. Tags are
+// only passed on by Sinks implementing TaggedSink.
+func Tags(tags ...Tag) MOption {
+	return MOption(func(m MConfig) {
+		m.cfg["tags"] = append([]Tag(nil), tags...)
+	})
+}
+
+// Aggregate returns a configuration option making a Timer or Histogram aggregate its events
+// client side instead of sending every event to the Sink.
+// At each flush the count, min, max, mean and the percentiles (default DefaultPercentiles)
+// of the events since the last flush are sent as gauges named like "name.count" and "name.p99"
+// ("name.p99_9" for 99.9). Percentiles are estimated with an error of about 3%
+// using a fixed size histogram, so memory use is bounded.
+// Provide this to Register*
+func Aggregate(percentiles ...float64) MOption {
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+	return MOption(func(m MConfig) {
+		m.cfg["aggregate"] = append([]float64(nil), percentiles...)
+	})
+}
@@ -12,6 +12,7 @@ import (
 // This is often not the case, so the AdHocSetMember is often a simpler solution.
 type Set struct {
 	name string
+	tags []Tag
 
 	mu  sync.Mutex
 	set map[string]struct{}
@@ -29,11 +30,15 @@ func (s *Set) FlushReading(f Sink) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for k, _ := range s.set {
-		f.Record(MeterSet, s.name, k)
+		record(f, MeterSet, s.name, s.tags, k)
 	}
 	s.set = make(map[string]struct{})
 }
 
+func (s *Set) setTags(tags []Tag) {
+	s.tags = tags
+}
+
 // Name returns the name of the Set
 func (s *Set) Name() string {
 	return s.name
@@ -18,6 +18,15 @@ type Sink interface {
 	Flush()
 }
 
+// ErrorSink is implemented by Sinks which can report errors sending data.
+// This is an extension of the Sink interface used by sinks wrapping other sinks
+// - like to fail over to another Sink.
+type ErrorSink interface {
+	Sink
+	// FlushError returns the first error sending data since the last call to FlushError - or nil.
+	FlushError() error
+}
+
 // UnlockedSink implmentors can return a Sink which does not need to protect it self
 // against access from multiple go-routines.
 // This is used by flushing go-routines to save a lot of locking when flushing metric
@@ -0,0 +1,126 @@
+// Package interval implements what is common to Sinks aggregating readings in memory:
+// recording the readings of a flush interval by one flusher.
+package interval
+
+import (
+	"fmt"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/num64"
+	"strconv"
+	"sync"
+)
+
+// Window records readings of a flush interval, implementing the recording methods
+// of metric.Sink. Numeric values are passed on as float64 to the observe function given to Init.
+// The distinct members of Sets are collected in Sets - until the Sink handles them on Flush.
+type Window struct {
+	mu      *sync.Mutex
+	setKey  func(name string, tags []metric.Tag) string
+	observe func(mtype int, name string, tags []metric.Tag, v float64)
+
+	// Members of Sets by the key returned by setKey
+	Sets map[string]map[string]struct{}
+}
+
+// Init sets up the Window to record readings with mu (the Sink lock) held.
+// setKey returns the key of a Set metric and observe records a numeric value.
+func (w *Window) Init(mu *sync.Mutex, setKey func(name string, tags []metric.Tag) string,
+	observe func(mtype int, name string, tags []metric.Tag, v float64)) {
+	w.mu = mu
+	w.setKey = setKey
+	w.observe = observe
+	w.Sets = make(map[string]map[string]struct{})
+}
+
+// Record a value. Sets record their members as strings.
+func (w *Window) Record(mtype int, name string, value interface{}) {
+	w.RecordTagged(mtype, name, nil, value)
+}
+
+// RecordNumeric64 records a Numeric64 value
+func (w *Window) RecordNumeric64(mtype int, name string, value num64.Numeric64) {
+	w.RecordNumeric64Tagged(mtype, name, nil, value)
+}
+
+// RecordTagged records a value with tags
+func (w *Window) RecordTagged(mtype int, name string, tags []metric.Tag, value interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if mtype == metric.MeterSet {
+		var member string
+		switch v := value.(type) {
+		case string:
+			member = v
+		case fmt.Stringer:
+			member = v.String()
+		default:
+			member = fmt.Sprint(v)
+		}
+		key := w.setKey(name, tags)
+		members, ok := w.Sets[key]
+		if !ok {
+			members = make(map[string]struct{})
+			w.Sets[key] = members
+		}
+		members[member] = struct{}{}
+		return
+	}
+
+	if v, ok := ToFloat(value); ok {
+		w.observe(mtype, name, tags, v)
+	}
+}
+
+// RecordNumeric64Tagged records a Numeric64 value with tags
+func (w *Window) RecordNumeric64Tagged(mtype int, name string, tags []metric.Tag, value num64.Numeric64) {
+	var v float64
+	switch value.Type {
+	case num64.Uint64:
+		v = float64(value.Uint64())
+	case num64.Int64:
+		v = float64(value.Int64())
+	case num64.Float64:
+		v = value.Float64()
+	}
+	w.mu.Lock()
+	w.observe(mtype, name, tags, v)
+	w.mu.Unlock()
+}
+
+// ToFloat converts a numeric value - or a string or fmt.Stringer with a number - to float64.
+func ToFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	case fmt.Stringer:
+		f, err := strconv.ParseFloat(n.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
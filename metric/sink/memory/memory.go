@@ -0,0 +1,296 @@
+// Package memory implements a metric.Sink keeping the current values of all metrics in memory
+// for introspection. The values can be queried with Snapshot() or as JSON over HTTP:
+//
+//	sink := memory.New()
+//	client := metric.NewClient(sink, metric.FlushInterval(10*time.Second))
+//	http.Handle("/debug/metrics", sink)
+//
+// A Reading holds the current value of a metric as of the last flush of its Meter:
+//
+//   - Gauges: the last value.
+//   - Counters: the total since the Sink was created.
+//   - Sets: the number of distinct members in the last flush interval.
+//   - Timers and Histograms: the last event.
+//
+// For Counters, Timers and Histograms Recent holds the count, sum, min, max and mean of the
+// events in the last flush interval having any events.
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/num64"
+	"github.com/One-com/gone/metric/sink/internal/interval"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TypeNames are the Reading types of the metric.Meter types
+var TypeNames = map[int]string{
+	metric.MeterGauge:     "gauge",
+	metric.MeterCounter:   "counter",
+	metric.MeterHistogram: "histogram",
+	metric.MeterTimer:     "timer",
+	metric.MeterSet:       "set",
+}
+
+// Stats are aggregates of the events of a metric during a flush interval.
+type Stats struct {
+	Count uint64  `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+}
+
+// Reading is the current value of a metric.
+type Reading struct {
+	Name    string            `json:"name"`
+	Type    string            `json:"type"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Value   float64           `json:"value"`
+	Recent  *Stats            `json:"recent,omitempty"`
+	Updated time.Time         `json:"updated"`
+}
+
+type entry struct {
+	mtype   int
+	key     string
+	reading Reading
+}
+
+// window is what is recorded during a flush interval by one flusher.
+// Maps are by entry key.
+type window struct {
+	interval.Window
+	sink  *Sink
+	stats map[string]*Stats
+}
+
+// Sink keeps the current values of metrics.
+// Each Client flusher gets its own view of the Sink by UnlockedSink(), so Recent stats and Set sizes
+// are calculated over the flush interval of the Meter.
+type Sink struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	own     window // for readings recorded directly with the Sink
+}
+
+// New creates a memory Sink
+func New() *Sink {
+	s := &Sink{entries: make(map[string]*entry)}
+	s.own.init(s)
+	return s
+}
+
+func (w *window) init(s *Sink) {
+	w.sink = s
+	w.stats = make(map[string]*Stats)
+	w.Window.Init(&s.mu, func(name string, tags []metric.Tag) string {
+		return s.get(metric.MeterSet, name, tags).key
+	}, w.observe)
+}
+
+// UnlockedSink returns a view of the Sink for a Client flusher
+func (s *Sink) UnlockedSink() metric.Sink {
+	w := &window{}
+	w.init(s)
+	return w
+}
+
+// Record a value with the sink. Sets record their members as strings.
+func (s *Sink) Record(mtype int, name string, value interface{}) {
+	s.own.RecordTagged(mtype, name, nil, value)
+}
+
+// RecordNumeric64 records a Numeric64 value with the sink
+func (s *Sink) RecordNumeric64(mtype int, name string, value num64.Numeric64) {
+	s.own.RecordNumeric64Tagged(mtype, name, nil, value)
+}
+
+// RecordTagged records a value with tags
+func (s *Sink) RecordTagged(mtype int, name string, tags []metric.Tag, value interface{}) {
+	s.own.RecordTagged(mtype, name, tags, value)
+}
+
+// RecordNumeric64Tagged records a Numeric64 value with tags
+func (s *Sink) RecordNumeric64Tagged(mtype int, name string, tags []metric.Tag, value num64.Numeric64) {
+	s.own.RecordNumeric64Tagged(mtype, name, tags, value)
+}
+
+// Flush ends the flush interval of readings recorded directly with the Sink.
+func (s *Sink) Flush() {
+	s.own.Flush()
+}
+
+// Flush ends the flush interval: Set sizes and Recent stats are updated
+// for metrics having readings in the interval.
+func (w *window) Flush() {
+	s := w.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, members := range w.Sets {
+		delete(w.Sets, key)
+		if e, ok := s.entries[key]; ok && e.mtype == metric.MeterSet {
+			e.reading.Value = float64(len(members))
+			e.reading.Updated = now
+		}
+	}
+	for key, stats := range w.stats {
+		delete(w.stats, key)
+		if e, ok := s.entries[key]; ok && e.mtype != metric.MeterSet && e.mtype != metric.MeterGauge {
+			stats.Mean = stats.Sum / float64(stats.Count)
+			e.reading.Recent = stats
+		}
+	}
+}
+
+// observe a value of a metric. The Sink lock must be held.
+func (w *window) observe(mtype int, name string, tags []metric.Tag, v float64) {
+	e := w.sink.get(mtype, name, tags)
+	e.reading.Updated = time.Now()
+	switch mtype {
+	case metric.MeterGauge:
+		e.reading.Value = v
+		return
+	case metric.MeterCounter:
+		e.reading.Value += v
+	case metric.MeterTimer, metric.MeterHistogram:
+		e.reading.Value = v
+	default:
+		return
+	}
+	st, ok := w.stats[e.key]
+	if !ok {
+		st = &Stats{Min: v, Max: v}
+		w.stats[e.key] = st
+	}
+	st.Count++
+	st.Sum += v
+	st.Min = math.Min(st.Min, v)
+	st.Max = math.Max(st.Max, v)
+}
+
+// get the entry of a metric, creating it if needed. The Sink lock must be held.
+// A metric reported as another type is reset.
+func (s *Sink) get(mtype int, name string, tags []metric.Tag) *entry {
+	key := entryKey(name, tags)
+	e, ok := s.entries[key]
+	if ok && e.mtype == mtype {
+		return e
+	}
+	e = &entry{mtype: mtype, key: key}
+	e.reading.Name = name
+	e.reading.Type = TypeNames[mtype]
+	if len(tags) > 0 {
+		e.reading.Tags = make(map[string]string, len(tags))
+		for _, t := range tags {
+			e.reading.Tags[t.Key] = t.Value
+		}
+	}
+	s.entries[key] = e
+	return e
+}
+
+// entryKey identifies a metric by name and tags, like: name{key=value,key2=value2}
+func entryKey(name string, tags []metric.Tag) string {
+	if len(tags) == 0 {
+		return name
+	}
+	sorted := append([]metric.Tag(nil), tags...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, t := range sorted {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(t.Key)
+		b.WriteByte('=')
+		b.WriteString(t.Value)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Snapshot returns the current Readings of metrics with names starting with prefix,
+// sorted by name and tags.
+func (s *Sink) Snapshot(prefix string) []Reading {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]*entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if strings.HasPrefix(e.reading.Name, prefix) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	readings := make([]Reading, len(entries))
+	for i, e := range entries {
+		r := e.reading
+		if r.Tags != nil {
+			r.Tags = make(map[string]string, len(e.reading.Tags))
+			for k, v := range e.reading.Tags {
+				r.Tags[k] = v
+			}
+		}
+		if r.Recent != nil {
+			recent := *r.Recent
+			r.Recent = &recent
+		}
+		readings[i] = r
+	}
+	return readings
+}
+
+// String formats the Reading on one line, like:
+//
+//	http.requests{code=200} counter 1234 (recent count=10 sum=17 min=1 max=3 mean=1.7)
+func (r Reading) String() string {
+	var b strings.Builder
+	b.WriteString(entryKey(r.Name, r.tags()))
+	b.WriteByte(' ')
+	b.WriteString(r.Type)
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(r.Value, 'f', -1, 64))
+	if r.Recent != nil {
+		fmt.Fprintf(&b, " (recent count=%d sum=%s min=%s max=%s mean=%s)", r.Recent.Count,
+			strconv.FormatFloat(r.Recent.Sum, 'f', -1, 64),
+			strconv.FormatFloat(r.Recent.Min, 'f', -1, 64),
+			strconv.FormatFloat(r.Recent.Max, 'f', -1, 64),
+			strconv.FormatFloat(r.Recent.Mean, 'f', -1, 64))
+	}
+	return b.String()
+}
+
+func (r Reading) tags() []metric.Tag {
+	if len(r.Tags) == 0 {
+		return nil
+	}
+	tags := make([]metric.Tag, 0, len(r.Tags))
+	for k, v := range r.Tags {
+		tags = append(tags, metric.Tag{Key: k, Value: v})
+	}
+	return tags
+}
+
+// ServeHTTP writes a JSON array of the current Readings.
+// The "prefix" query parameter selects metrics with names starting with the prefix.
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	readings := s.Snapshot(r.URL.Query().Get("prefix"))
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(readings)
+}
@@ -0,0 +1,77 @@
+package memory_test
+
+import (
+	"encoding/json"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/sink/memory"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	sink := memory.New()
+	client := metric.NewClient(sink)
+
+	counter := client.RegisterCounter("http.requests", metric.Tags(metric.Tag{Key: "code", Value: "200"}))
+	gauge := client.RegisterGauge("http.conns")
+	timer := client.RegisterTimer("http.latency")
+	set := client.RegisterSet("users")
+
+	counter.Inc(2)
+	counter.Inc(3)
+	gauge.Set(7)
+	timer.Sample(10 * time.Millisecond)
+	timer.Sample(30 * time.Millisecond)
+	set.Add("a")
+	set.Add("b")
+	client.Flush()
+
+	counter.Inc(1)
+	client.Flush()
+	// A flush without events keeps the recent stats
+	client.Flush()
+
+	got := sink.Snapshot("http.")
+	for i := range got {
+		got[i].Updated = time.Time{}
+	}
+	expected := []memory.Reading{
+		{Name: "http.conns", Type: "gauge", Value: 7},
+		{Name: "http.latency", Type: "timer", Value: 30,
+			Recent: &memory.Stats{Count: 2, Sum: 40, Min: 10, Max: 30, Mean: 20}},
+		{Name: "http.requests", Type: "counter", Tags: map[string]string{"code": "200"}, Value: 6,
+			Recent: &memory.Stats{Count: 1, Sum: 1, Min: 1, Max: 1, Mean: 1}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got:\n%+v\nExpected:\n%+v", got, expected)
+	}
+	if s := got[2].String(); s != "http.requests{code=200} counter 6 (recent count=1 sum=1 min=1 max=1 mean=1)" {
+		t.Errorf("Wrong String(): %s", s)
+	}
+
+	all := sink.Snapshot("")
+	if len(all) != 4 || all[3].Name != "users" || all[3].Value != 2 {
+		t.Errorf("Wrong set reading: %+v", all)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	sink := memory.New()
+	metric.NewGauge("a.gauge").FlushReading(sink)
+	metric.NewGauge("b.gauge").FlushReading(sink)
+
+	rec := httptest.NewRecorder()
+	sink.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics?prefix=b.", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	var readings []memory.Reading
+	if err := json.Unmarshal(rec.Body.Bytes(), &readings); err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 1 || readings[0].Name != "b.gauge" || readings[0].Type != "gauge" {
+		t.Errorf("Wrong readings: %+v", readings)
+	}
+}
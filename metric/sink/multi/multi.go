@@ -0,0 +1,265 @@
+// Package multi implements metric Sinks combining other Sinks:
+// Fanout duplicating readings to several Sinks and Failover switching to a secondary
+// Sink when the primary fails.
+//
+// Both support UnlockedSink(), giving each Client flusher unlocked versions of the
+// combined Sinks, if they support it.
+package multi
+
+import (
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/num64"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type unlockedSink interface {
+	UnlockedSink() metric.Sink
+}
+
+// unlocked returns the unlocked version of s if it has one
+func unlocked(s metric.Sink) metric.Sink {
+	if u, ok := s.(unlockedSink); ok {
+		return u.UnlockedSink()
+	}
+	return s
+}
+
+func recordTagged(s metric.Sink, mtype int, name string, tags []metric.Tag, value interface{}) {
+	if ts, ok := s.(metric.TaggedSink); ok {
+		ts.RecordTagged(mtype, name, tags, value)
+		return
+	}
+	s.Record(mtype, name, value)
+}
+
+func recordNumeric64Tagged(s metric.Sink, mtype int, name string, tags []metric.Tag, value num64.Numeric64) {
+	if ts, ok := s.(metric.TaggedSink); ok {
+		ts.RecordNumeric64Tagged(mtype, name, tags, value)
+		return
+	}
+	s.RecordNumeric64(mtype, name, value)
+}
+
+func flushError(s metric.Sink) error {
+	if es, ok := s.(metric.ErrorSink); ok {
+		return es.FlushError()
+	}
+	return nil
+}
+
+//---
+
+// Fanout is a Sink recording all readings with several Sinks.
+// It's go-routine safe if the Sinks are.
+type Fanout struct {
+	sinks []metric.Sink
+}
+
+// NewFanout creates a Sink duplicating readings to all the sinks.
+func NewFanout(sinks ...metric.Sink) *Fanout {
+	return &Fanout{sinks: append([]metric.Sink(nil), sinks...)}
+}
+
+// UnlockedSink returns a Fanout to the unlocked versions of the Sinks.
+func (f *Fanout) UnlockedSink() metric.Sink {
+	u := &Fanout{sinks: make([]metric.Sink, len(f.sinks))}
+	for i, s := range f.sinks {
+		u.sinks[i] = unlocked(s)
+	}
+	return u
+}
+
+// Record a value with all the sinks
+func (f *Fanout) Record(mtype int, name string, value interface{}) {
+	for _, s := range f.sinks {
+		s.Record(mtype, name, value)
+	}
+}
+
+// RecordNumeric64 records a Numeric64 value with all the sinks
+func (f *Fanout) RecordNumeric64(mtype int, name string, value num64.Numeric64) {
+	for _, s := range f.sinks {
+		s.RecordNumeric64(mtype, name, value)
+	}
+}
+
+// RecordTagged records a value with tags with all the sinks
+func (f *Fanout) RecordTagged(mtype int, name string, tags []metric.Tag, value interface{}) {
+	for _, s := range f.sinks {
+		recordTagged(s, mtype, name, tags, value)
+	}
+}
+
+// RecordNumeric64Tagged records a Numeric64 value with tags with all the sinks
+func (f *Fanout) RecordNumeric64Tagged(mtype int, name string, tags []metric.Tag, value num64.Numeric64) {
+	for _, s := range f.sinks {
+		recordNumeric64Tagged(s, mtype, name, tags, value)
+	}
+}
+
+// Flush all the sinks
+func (f *Fanout) Flush() {
+	for _, s := range f.sinks {
+		s.Flush()
+	}
+}
+
+// FlushError returns the first error of any of the sinks - implementing metric.ErrorSink.
+// The errors of all the sinks are cleared.
+func (f *Fanout) FlushError() (err error) {
+	for _, s := range f.sinks {
+		if serr := flushError(s); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return
+}
+
+//---
+
+// The state shared by a Failover and its unlocked versions
+type failoverState struct {
+	failAfter int
+	retry     time.Duration
+
+	secondary int32 // the secondary is active. Read atomically when recording
+
+	mu       sync.Mutex
+	failures int // consecutive flush errors of the primary
+	switched time.Time
+}
+
+// Failover is a Sink recording readings with a primary Sink until it has had a number of
+// consecutive Flush errors. Then the readings are recorded with a secondary Sink for a while
+// before trying the primary again.
+// Flush errors are detected if the primary implements metric.ErrorSink - like the statsd Sink.
+// The statsd Sink drops the data it fails to write, so the readings of the failing flush
+// intervals before switching to the secondary are lost.
+// It's go-routine safe if the Sinks are.
+type Failover struct {
+	sinks    [2]metric.Sink
+	state    *failoverState
+	recorded [2]int32 // readings recorded with each sink since last Flush
+}
+
+// FailoverOption configures a Failover Sink
+type FailoverOption func(*Failover)
+
+// FailAfter sets the number of consecutive Flush errors of the primary before switching to the secondary.
+// Default 3.
+func FailAfter(n int) FailoverOption {
+	return func(f *Failover) {
+		if n < 1 {
+			n = 1
+		}
+		f.state.failAfter = n
+	}
+}
+
+// RetryPrimary sets how long to use the secondary before trying the primary again. Default 1 minute.
+func RetryPrimary(d time.Duration) FailoverOption {
+	return func(f *Failover) {
+		f.state.retry = d
+	}
+}
+
+// NewFailover creates a Sink using primary - or secondary when primary fails.
+func NewFailover(primary, secondary metric.Sink, opts ...FailoverOption) *Failover {
+	f := &Failover{
+		sinks: [2]metric.Sink{primary, secondary},
+		state: &failoverState{failAfter: 3, retry: time.Minute},
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// UnlockedSink returns a Failover between unlocked versions of the Sinks, sharing
+// the state of which Sink is in use.
+func (f *Failover) UnlockedSink() metric.Sink {
+	return &Failover{
+		sinks: [2]metric.Sink{unlocked(f.sinks[0]), unlocked(f.sinks[1])},
+		state: f.state,
+	}
+}
+
+// UsingSecondary returns whether the secondary Sink is in use
+func (f *Failover) UsingSecondary() bool {
+	return atomic.LoadInt32(&f.state.secondary) != 0
+}
+
+func (f *Failover) active() metric.Sink {
+	if f.UsingSecondary() {
+		atomic.StoreInt32(&f.recorded[1], 1)
+		return f.sinks[1]
+	}
+	atomic.StoreInt32(&f.recorded[0], 1)
+	return f.sinks[0]
+}
+
+// Record a value with the active sink
+func (f *Failover) Record(mtype int, name string, value interface{}) {
+	f.active().Record(mtype, name, value)
+}
+
+// RecordNumeric64 records a Numeric64 value with the active sink
+func (f *Failover) RecordNumeric64(mtype int, name string, value num64.Numeric64) {
+	f.active().RecordNumeric64(mtype, name, value)
+}
+
+// RecordTagged records a value with tags with the active sink
+func (f *Failover) RecordTagged(mtype int, name string, tags []metric.Tag, value interface{}) {
+	recordTagged(f.active(), mtype, name, tags, value)
+}
+
+// RecordNumeric64Tagged records a Numeric64 value with tags with the active sink
+func (f *Failover) RecordNumeric64Tagged(mtype int, name string, tags []metric.Tag, value num64.Numeric64) {
+	recordNumeric64Tagged(f.active(), mtype, name, tags, value)
+}
+
+// Flush the active sink and switch sink if needed.
+// The inactive sink is flushed too, if it has readings recorded before another flusher switched sink.
+// Only flushes of recorded readings count as failed or successful.
+func (f *Failover) Flush() {
+	recorded := atomic.SwapInt32(&f.recorded[0], 0) != 0
+	recordedSecondary := atomic.SwapInt32(&f.recorded[1], 0) != 0
+	st := f.state
+
+	if f.UsingSecondary() {
+		if recorded {
+			f.sinks[0].Flush()
+			flushError(f.sinks[0]) // not counted, already switched
+		}
+		f.sinks[1].Flush()
+		st.mu.Lock()
+		if f.UsingSecondary() && time.Since(st.switched) >= st.retry {
+			// Try the primary. A single error switches back.
+			atomic.StoreInt32(&st.secondary, 0)
+			st.failures = st.failAfter - 1
+		}
+		st.mu.Unlock()
+		return
+	}
+
+	if recordedSecondary {
+		f.sinks[1].Flush()
+	}
+	f.sinks[0].Flush()
+	err := flushError(f.sinks[0])
+	if !recorded && err == nil {
+		return
+	}
+	st.mu.Lock()
+	if !f.UsingSecondary() {
+		if err == nil {
+			st.failures = 0
+		} else if st.failures++; st.failures >= st.failAfter {
+			st.switched = time.Now()
+			atomic.StoreInt32(&st.secondary, 1)
+		}
+	}
+	st.mu.Unlock()
+}
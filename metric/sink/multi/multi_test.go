@@ -0,0 +1,146 @@
+package multi_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/num64"
+	"github.com/One-com/gone/metric/sink/multi"
+	"github.com/One-com/gone/metric/sink/statsd"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A writer which can be made to fail
+type output struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	fail bool
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.fail {
+		return 0, errors.New("down")
+	}
+	return o.buf.Write(p)
+}
+
+func (o *output) setFail(fail bool) {
+	o.mu.Lock()
+	o.fail = fail
+	o.mu.Unlock()
+}
+
+func (o *output) take() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s := o.buf.String()
+	o.buf.Reset()
+	return s
+}
+
+func newStatsd(t *testing.T, out *output, opts ...statsd.Option) metric.Sink {
+	sink, err := statsd.New(append([]statsd.Option{statsd.Buffer(512), statsd.Output(out)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sink
+}
+
+func TestFanout(t *testing.T) {
+	var old, cur output
+	sink := multi.NewFanout(
+		newStatsd(t, &old),
+		newStatsd(t, &cur, statsd.TagDialect(statsd.DogStatsD)))
+
+	client := metric.NewClient(sink)
+	counter := client.RegisterCounter("requests", metric.Tags(metric.Tag{Key: "code", Value: "200"}))
+	counter.Inc(2)
+	client.AdhocGauge("conns", 3, false)
+	client.Flush()
+
+	if out := old.take(); out != "conns:3|g\nrequests:2|c\n" {
+		t.Errorf("Wrong output %q", out)
+	}
+	if out := cur.take(); out != "conns:3|g\nrequests:2|c|#code:200\n" {
+		t.Errorf("Wrong output %q", out)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	var primary, secondary output
+	sink := multi.NewFailover(newStatsd(t, &primary), newStatsd(t, &secondary),
+		multi.FailAfter(2), multi.RetryPrimary(50*time.Millisecond))
+
+	client := metric.NewClient(sink)
+	gauge := client.RegisterGauge("gauge")
+	gauge.Set(1)
+
+	client.Flush()
+	if out := primary.take(); out != "gauge:1|g\n" {
+		t.Errorf("Wrong primary output %q", out)
+	}
+
+	primary.setFail(true)
+	client.Flush()
+	if sink.UsingSecondary() {
+		t.Error("Failed over after a single error")
+	}
+	client.Flush()
+	if !sink.UsingSecondary() {
+		t.Fatal("Not failed over after 2 errors")
+	}
+	client.Flush()
+	if out := secondary.take(); out != "gauge:1|g\n" {
+		t.Errorf("Wrong secondary output %q", out)
+	}
+
+	// Retry the primary - still failing.
+	time.Sleep(60 * time.Millisecond)
+	client.Flush() // secondary flushed, then the primary is retried
+	client.Flush()
+	if !sink.UsingSecondary() {
+		t.Error("Not failed over again after a single error of the retried primary")
+	}
+	secondary.take()
+
+	// Primary recovers
+	primary.setFail(false)
+	time.Sleep(60 * time.Millisecond)
+	client.Flush()
+	client.Flush()
+	if sink.UsingSecondary() {
+		t.Error("Primary not used after recovery")
+	}
+	if out := primary.take(); out != "gauge:1|g\n" {
+		t.Errorf("Wrong primary output %q", out)
+	}
+}
+
+// Readings recorded by other flushers before the switch are not left behind
+func TestFailoverFlushers(t *testing.T) {
+	var primary, secondary output
+	sink := multi.NewFailover(newStatsd(t, &primary), newStatsd(t, &secondary), multi.FailAfter(1))
+	fast, slow := sink.UnlockedSink(), sink.UnlockedSink()
+
+	primary.setFail(true)
+	fast.RecordNumeric64(metric.MeterGauge, "fast", num64.FromInt64(1))
+	slow.RecordNumeric64(metric.MeterGauge, "slow", num64.FromInt64(2))
+	fast.Flush()
+	if !sink.UsingSecondary() {
+		t.Fatal("Not failed over")
+	}
+
+	primary.setFail(false)
+	slow.RecordNumeric64(metric.MeterGauge, "slow", num64.FromInt64(3))
+	slow.Flush()
+	if out := primary.take(); out != "slow:2|g\n" {
+		t.Errorf("Wrong primary output %q", out)
+	}
+	if out := secondary.take(); out != "slow:3|g\n" {
+		t.Errorf("Wrong secondary output %q", out)
+	}
+}
@@ -0,0 +1,503 @@
+// Package prometheus implements a pull style metric.Sink aggregating Meter readings
+// and serving them in the Prometheus text exposition format.
+//
+// The Sink is an http.Handler, to be mounted on any HTTP server:
+//
+//	sink, _ := prometheus.New(prometheus.Namespace("myapp"))
+//	client := metric.NewClient(sink, metric.FlushInterval(10*time.Second))
+//	http.Handle("/metrics", sink)
+//
+// Readings are aggregated like statsd would do it:
+//
+//   - Counters are accumulated. Counters decreased by negative values are exposed as gauges.
+//   - Gauges keep the last value.
+//   - Timers and Histograms are observed into cumulative buckets - or summaries with quantiles.
+//   - Sets are exposed as a gauge with the number of distinct members seen in the last flush interval.
+//
+// Tags of Meters (see metric.Tags) are exposed as labels.
+//
+// Values are exposed as they are flushed by the Client, so a scrape sees the state
+// as of the last flush of each Meter. Summary quantiles and Set sizes are calculated per flush interval
+// and kept until a later flush interval has readings for the metric.
+// Timer values are exposed in seconds - as Prometheus recommends - so bucket bounds for Timers are seconds.
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/num64"
+	"github.com/One-com/gone/metric/sink/internal/interval"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ContentType is the content type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the default histogram bucket upper bounds - tuned for Timers in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Option is the type of configuration options for the Prometheus sink factory.
+type Option func(*Sink) error
+
+// An aggregated metric
+type series struct {
+	mtype  int
+	name   string
+	labels string  // rendered tags
+	value  float64 // counter sum, gauge value or set size
+
+	decreased bool // a counter has had negative deltas
+
+	// Timers and Histograms
+	bounds    []float64 // bucket upper bounds
+	counts    []uint64  // observations per bucket (not cumulative). The last is +Inf
+	quantiles []float64 // for summaries
+	qvalues   []float64 // the quantile values of the last flush interval
+	sum       float64
+	count     uint64
+}
+
+// window is what is recorded during a flush interval by one flusher.
+// Maps are by series key.
+type window struct {
+	interval.Window
+	sink    *Sink
+	samples map[string][]float64
+}
+
+// Sink aggregates metrics and serves them to Prometheus.
+// Each Client flusher gets its own view of the Sink by UnlockedSink(), so Set sizes and summary
+// quantiles are calculated over the flush interval of the Meter.
+type Sink struct {
+	mu        sync.Mutex
+	namespace string
+	buckets   []float64
+	quantiles []float64
+	perMetric map[string]func(*series)
+	series    map[string]*series
+	own       window // for readings recorded directly with the Sink
+}
+
+// Namespace is prepended with "namespace_" to all metric names
+func Namespace(ns string) Option {
+	return Option(func(s *Sink) error {
+		s.namespace = sanitize(ns) + "_"
+		return nil
+	})
+}
+
+// Buckets sets the bucket upper bounds of Timers and Histograms. (Default DefaultBuckets)
+func Buckets(bounds ...float64) Option {
+	return Option(func(s *Sink) error {
+		b, err := checkBuckets(bounds)
+		s.buckets = b
+		return err
+	})
+}
+
+// Summary makes Timers and Histograms summaries with the given quantiles (like 0.5, 0.9, 0.99)
+// instead of histograms with buckets.
+func Summary(quantiles ...float64) Option {
+	return Option(func(s *Sink) error {
+		q, err := checkQuantiles(quantiles)
+		s.quantiles = q
+		return err
+	})
+}
+
+// MetricBuckets sets the bucket upper bounds of the named Timer or Histogram, overriding
+// Buckets() and Summary().
+func MetricBuckets(name string, bounds ...float64) Option {
+	return Option(func(s *Sink) error {
+		b, err := checkBuckets(bounds)
+		s.perMetric[name] = func(se *series) {
+			se.bounds = b
+			se.quantiles = nil
+		}
+		return err
+	})
+}
+
+// MetricSummary makes the named Timer or Histogram a summary with the given quantiles,
+// overriding Buckets() and Summary().
+func MetricSummary(name string, quantiles ...float64) Option {
+	return Option(func(s *Sink) error {
+		q, err := checkQuantiles(quantiles)
+		s.perMetric[name] = func(se *series) {
+			se.bounds = nil
+			se.quantiles = q
+		}
+		return err
+	})
+}
+
+func checkBuckets(bounds []float64) ([]float64, error) {
+	b := make([]float64, 0, len(bounds))
+	for _, v := range bounds {
+		if math.IsNaN(v) || math.IsInf(v, +1) {
+			continue // +Inf is always added
+		}
+		b = append(b, v)
+	}
+	if !sort.Float64sAreSorted(b) {
+		return nil, fmt.Errorf("Histogram buckets must be in increasing order: %v", bounds)
+	}
+	return b, nil
+}
+
+func checkQuantiles(quantiles []float64) ([]float64, error) {
+	if len(quantiles) == 0 {
+		return nil, fmt.Errorf("Summary needs quantiles")
+	}
+	for _, q := range quantiles {
+		if !(q >= 0 && q <= 1) {
+			return nil, fmt.Errorf("Quantile %v not in [0,1]", q)
+		}
+	}
+	q := append([]float64(nil), quantiles...)
+	sort.Float64s(q)
+	return q, nil
+}
+
+// New creates a Prometheus Sink.
+func New(opts ...Option) (sink *Sink, err error) {
+	s := &Sink{
+		buckets:   DefaultBuckets,
+		perMetric: make(map[string]func(*series)),
+		series:    make(map[string]*series),
+	}
+	s.own.init(s)
+
+	for _, o := range opts {
+		err = o(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sink = s
+	return
+}
+
+func (w *window) init(s *Sink) {
+	w.sink = s
+	w.samples = make(map[string][]float64)
+	w.Window.Init(&s.mu, func(name string, tags []metric.Tag) string {
+		return s.get(metric.MeterSet, name, tags).key()
+	}, w.observe)
+}
+
+// UnlockedSink returns a view of the Sink for a Client flusher
+func (s *Sink) UnlockedSink() metric.Sink {
+	w := &window{}
+	w.init(s)
+	return w
+}
+
+// Record a value with the sink. Sets record their members as strings.
+func (s *Sink) Record(mtype int, name string, value interface{}) {
+	s.own.RecordTagged(mtype, name, nil, value)
+}
+
+// RecordNumeric64 records a Numeric64 value with the sink
+func (s *Sink) RecordNumeric64(mtype int, name string, value num64.Numeric64) {
+	s.own.RecordNumeric64Tagged(mtype, name, nil, value)
+}
+
+// RecordTagged records a value with tags exposed as labels.
+func (s *Sink) RecordTagged(mtype int, name string, tags []metric.Tag, value interface{}) {
+	s.own.RecordTagged(mtype, name, tags, value)
+}
+
+// RecordNumeric64Tagged records a Numeric64 value with tags exposed as labels.
+func (s *Sink) RecordNumeric64Tagged(mtype int, name string, tags []metric.Tag, value num64.Numeric64) {
+	s.own.RecordNumeric64Tagged(mtype, name, tags, value)
+}
+
+// Flush ends the flush interval of readings recorded directly with the Sink.
+func (s *Sink) Flush() {
+	s.own.Flush()
+}
+
+// Flush ends the flush interval: Set sizes and summary quantiles are calculated
+// for metrics having readings in the interval.
+func (w *window) Flush() {
+	s := w.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, members := range w.Sets {
+		delete(w.Sets, key)
+		if se, ok := s.series[key]; ok && se.mtype == metric.MeterSet {
+			se.value = float64(len(members))
+		}
+	}
+	for key, samples := range w.samples {
+		delete(w.samples, key)
+		se, ok := s.series[key]
+		if !ok || se.quantiles == nil {
+			continue
+		}
+		sort.Float64s(samples)
+		for i, q := range se.quantiles {
+			se.qvalues[i] = quantile(samples, q)
+		}
+	}
+}
+
+// observe a value of a metric. The Sink lock must be held.
+func (w *window) observe(mtype int, name string, tags []metric.Tag, v float64) {
+	se := w.sink.get(mtype, name, tags)
+	switch mtype {
+	case metric.MeterCounter:
+		se.value += v
+		if v < 0 {
+			se.decreased = true
+		}
+	case metric.MeterGauge:
+		se.value = v
+	case metric.MeterTimer, metric.MeterHistogram:
+		if mtype == metric.MeterTimer {
+			v /= 1000 // milliseconds to seconds
+		}
+		se.sum += v
+		se.count++
+		if se.quantiles != nil {
+			key := se.key()
+			w.samples[key] = append(w.samples[key], v)
+			return
+		}
+		i := sort.SearchFloat64s(se.bounds, v) // first bound >= v
+		se.counts[i]++
+	}
+}
+
+// get the series of a metric, creating it if needed. The Sink lock must be held.
+// A metric reported as another type is reset.
+func (s *Sink) get(mtype int, name string, tags []metric.Tag) *series {
+	labels := renderLabels(tags)
+	key := seriesKey(name, labels)
+	se, ok := s.series[key]
+	if ok && se.mtype == mtype {
+		return se
+	}
+	se = &series{mtype: mtype, name: name, labels: labels}
+	if mtype == metric.MeterTimer || mtype == metric.MeterHistogram {
+		se.bounds = s.buckets
+		se.quantiles = s.quantiles
+		if s.quantiles != nil {
+			se.bounds = nil
+		}
+		if f, ok := s.perMetric[name]; ok {
+			f(se)
+		}
+		if se.quantiles != nil {
+			se.qvalues = make([]float64, len(se.quantiles))
+			for i := range se.qvalues {
+				se.qvalues[i] = math.NaN()
+			}
+		} else {
+			se.counts = make([]uint64, len(se.bounds)+1)
+		}
+	}
+	s.series[key] = se
+	return se
+}
+
+func seriesKey(name, labels string) string {
+	if labels == "" {
+		return name
+	}
+	return name + "{" + labels + "}"
+}
+
+func (se *series) key() string {
+	return seriesKey(se.name, se.labels)
+}
+
+// promType is the Prometheus metric type of the series.
+// Counters having been decreased are gauges, as Prometheus counters only go up.
+func (se *series) promType() string {
+	switch se.mtype {
+	case metric.MeterCounter:
+		if se.decreased {
+			return "gauge"
+		}
+		return "counter"
+	case metric.MeterTimer, metric.MeterHistogram:
+		if se.quantiles != nil {
+			return "summary"
+		}
+		return "histogram"
+	}
+	return "gauge"
+}
+
+// renderLabels renders tags as Prometheus labels sorted by name
+func renderLabels(tags []metric.Tag) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	sorted := append([]metric.Tag(nil), tags...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	var b bytes.Buffer
+	for i, t := range sorted {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(sanitize(t.Key))
+		b.WriteString(`="`)
+		for j := 0; j < len(t.Value); j++ {
+			switch c := t.Value[j]; c {
+			case '\\', '"':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			default:
+				b.WriteByte(c)
+			}
+		}
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// quantile of sorted samples by the nearest rank method. NaN if there are no samples.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// ServeHTTP writes all metrics in the Prometheus text exposition format.
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	s.WriteText(&buf)
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	if r.Method != http.MethodHead {
+		w.Write(buf.Bytes())
+	}
+}
+
+// WriteText writes all metrics sorted by name in the Prometheus text exposition format to buf.
+// Metrics with names only differing by characters invalid in Prometheus (like "a.b" and "a_b")
+// are exposed as one metric, leaving out series of another type or with the same labels.
+func (s *Sink) WriteText(buf *bytes.Buffer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type exposed struct {
+		*series
+		pname string
+		typ   string
+	}
+	all := make([]exposed, 0, len(s.series))
+	for _, se := range s.series {
+		all = append(all, exposed{series: se, pname: s.namespace + sanitize(se.name), typ: se.promType()})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].pname != all[j].pname {
+			return all[i].pname < all[j].pname
+		}
+		if all[i].labels != all[j].labels {
+			return all[i].labels < all[j].labels
+		}
+		return all[i].name < all[j].name
+	})
+
+	var num []byte
+	line := func(name, labels, extra string, v float64) {
+		buf.WriteString(name)
+		if labels != "" || extra != "" {
+			buf.WriteByte('{')
+			buf.WriteString(labels)
+			if labels != "" && extra != "" {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(extra)
+			buf.WriteByte('}')
+		}
+		buf.WriteByte(' ')
+		num = appendFloat(num[:0], v)
+		buf.Write(num)
+		buf.WriteByte('\n')
+	}
+	typeLine := func(name, typ string) {
+		buf.WriteString("# TYPE " + name + " " + typ + "\n")
+	}
+
+	// The TYPE line is only written once for all labels of a metric.
+	// Metric names sanitized to the same name can't be exposed with another type or the same labels.
+	var last exposed
+	for _, se := range all {
+		pname := se.pname
+		first := pname != last.pname
+		if !first && (se.typ != last.typ || se.labels == last.labels) {
+			continue
+		}
+		last = se
+		if first {
+			typeLine(pname, se.typ)
+		}
+		switch se.mtype {
+		case metric.MeterCounter, metric.MeterGauge, metric.MeterSet:
+			line(pname, se.labels, "", se.value)
+		case metric.MeterTimer, metric.MeterHistogram:
+			if se.quantiles != nil {
+				for i, q := range se.quantiles {
+					line(pname, se.labels, `quantile="`+string(appendFloat(nil, q))+`"`, se.qvalues[i])
+				}
+			} else {
+				var cum uint64
+				for i, c := range se.counts {
+					cum += c
+					le := math.Inf(+1)
+					if i < len(se.bounds) {
+						le = se.bounds[i]
+					}
+					line(pname+"_bucket", se.labels, `le="`+string(appendFloat(nil, le))+`"`, float64(cum))
+				}
+			}
+			line(pname+"_sum", se.labels, "", se.sum)
+			line(pname+"_count", se.labels, "", float64(se.count))
+		}
+	}
+}
+
+func appendFloat(buf []byte, v float64) []byte {
+	switch {
+	case math.IsInf(v, +1):
+		return append(buf, "+Inf"...)
+	case math.IsInf(v, -1):
+		return append(buf, "-Inf"...)
+	case math.IsNaN(v):
+		return append(buf, "NaN"...)
+	}
+	return strconv.AppendFloat(buf, v, 'g', -1, 64)
+}
+
+// sanitize makes a valid Prometheus metric name, replacing invalid characters (like '.') by '_'
+func sanitize(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' || c == ':' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9' && i > 0)
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,251 @@
+package prometheus_test
+
+import (
+	"github.com/One-com/gone/metric"
+	"github.com/One-com/gone/metric/sink/prometheus"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, h http.Handler) string {
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != prometheus.ContentType {
+		t.Errorf("Content-Type %q", ct)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestExposition(t *testing.T) {
+	sink, err := prometheus.New(prometheus.Namespace("app"), prometheus.Buckets(0.1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gauge := metric.NewGauge("conns")
+	counter := metric.NewCounter("http.requests")
+	timer := metric.NewTimer("latency")
+	set := metric.NewSet("users")
+
+	gauge.Set(3)
+	counter.Inc(2)
+	timer.Sample(50 * time.Millisecond)
+	timer.Sample(500 * time.Millisecond)
+	timer.Sample(2 * time.Second)
+	set.Add("a")
+	set.Add("b")
+	set.Add("a")
+	for _, m := range []metric.Meter{gauge, counter, timer, set} {
+		m.FlushReading(sink)
+	}
+	sink.Flush()
+
+	gauge.Set(7)
+	counter.Inc(3)
+	for _, m := range []metric.Meter{gauge, counter} {
+		m.FlushReading(sink)
+	}
+
+	expected := `# TYPE app_conns gauge
+app_conns 7
+# TYPE app_http_requests counter
+app_http_requests 5
+# TYPE app_latency histogram
+app_latency_bucket{le="0.1"} 1
+app_latency_bucket{le="1"} 2
+app_latency_bucket{le="+Inf"} 3
+app_latency_sum 2.55
+app_latency_count 3
+# TYPE app_users gauge
+app_users 2
+`
+	if got := scrape(t, sink); got != expected {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, expected)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	sink, err := prometheus.New(prometheus.Summary(0.5, 0.9), prometheus.MetricBuckets("size", 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	histo := metric.NewHistogram("rtt")
+	size := metric.NewHistogram("size")
+	for i := 1; i <= 10; i++ {
+		histo.Sample(int64(i))
+	}
+	size.Sample(20)
+	histo.FlushReading(sink)
+	size.FlushReading(sink)
+
+	// Quantiles are not known until the end of the flush interval
+	if got := scrape(t, sink); !strings.Contains(got, `rtt{quantile="0.5"} NaN`) {
+		t.Errorf("Expected NaN quantiles before flush, got:\n%s", got)
+	}
+	sink.Flush()
+
+	expected := `# TYPE rtt summary
+rtt{quantile="0.5"} 5
+rtt{quantile="0.9"} 9
+rtt_sum 55
+rtt_count 10
+# TYPE size histogram
+size_bucket{le="10"} 0
+size_bucket{le="+Inf"} 1
+size_sum 20
+size_count 1
+`
+	if got := scrape(t, sink); got != expected {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, expected)
+	}
+
+	// Intervals without samples keep the quantiles
+	sink.Flush()
+	if got := scrape(t, sink); got != expected {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, expected)
+	}
+	histo.Sample(100)
+	histo.FlushReading(sink)
+	sink.Flush()
+	if got := scrape(t, sink); !strings.Contains(got, `rtt{quantile="0.5"} 100`) || !strings.Contains(got, "rtt_count 11") {
+		t.Errorf("Expected quantiles of the last interval, got:\n%s", got)
+	}
+}
+
+// Sets are sized per flush interval of the flusher they are registered with
+func TestFlushIntervals(t *testing.T) {
+	sink, err := prometheus.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := metric.NewClient(sink, metric.FlushInterval(time.Hour))
+	client.Start()
+	defer client.Stop()
+	fast := client.RegisterSet("fast", metric.FlushInterval(10*time.Millisecond))
+	slow := client.RegisterSet("slow")
+
+	fast.Add("x")
+	fast.Add("y")
+	slow.Add("x")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(scrape(t, sink), "fast 2\n") {
+		if time.Now().After(deadline) {
+			t.Fatal("fast set not flushed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := scrape(t, sink)
+	if strings.Contains(got, "slow") {
+		t.Errorf("slow set flushed early:\n%s", got)
+	}
+
+	client.Flush()
+	got = scrape(t, sink)
+	if !strings.Contains(got, "slow 1\n") {
+		t.Errorf("Expected slow set size, got:\n%s", got)
+	}
+}
+
+func TestBadOptions(t *testing.T) {
+	if _, err := prometheus.New(prometheus.Buckets(2, 1)); err == nil {
+		t.Error("Expected error for unsorted buckets")
+	}
+	if _, err := prometheus.New(prometheus.Summary(1.5)); err == nil {
+		t.Error("Expected error for bad quantile")
+	}
+}
+
+func ExampleNew() {
+	sink, err := prometheus.New(prometheus.Namespace("prefix"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	counter := metric.NewCounter("counter")
+	counter.Inc(2)
+	counter.Inc(3)
+	counter.FlushReading(sink)
+	sink.Flush()
+
+	rec := httptest.NewRecorder()
+	sink.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	os.Stdout.Write(rec.Body.Bytes())
+	// Output:
+	// # TYPE prefix_counter counter
+	// prefix_counter 5
+}
+
+func TestLabels(t *testing.T) {
+	sink, err := prometheus.New(prometheus.Buckets(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := metric.NewClient(sink)
+	ok := client.RegisterCounter("requests", metric.Tags(metric.Tag{Key: "code", Value: "200"}, metric.Tag{Key: "method", Value: "GET"}))
+	notFound := client.RegisterCounter("requests", metric.Tags(metric.Tag{Key: "method", Value: "GET"}, metric.Tag{Key: "code", Value: "404"}))
+	timer := client.RegisterTimer("latency", metric.Tags(metric.Tag{Key: "path", Value: `/a"b`}))
+
+	ok.Inc(5)
+	notFound.Inc(1)
+	timer.Sample(100 * time.Millisecond)
+	client.AdhocSetMember("users", "x", false, metric.Tag{Key: "site", Value: "dk"})
+	client.Flush()
+
+	expected := `# TYPE latency histogram
+latency_bucket{path="/a\"b",le="1"} 1
+latency_bucket{path="/a\"b",le="+Inf"} 1
+latency_sum{path="/a\"b"} 0.1
+latency_count{path="/a\"b"} 1
+# TYPE requests counter
+requests{code="200",method="GET"} 5
+requests{code="404",method="GET"} 1
+# TYPE users gauge
+users{site="dk"} 1
+`
+	if got := scrape(t, sink); got != expected {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, expected)
+	}
+}
+
+func TestSanitizedNames(t *testing.T) {
+	sink, err := prometheus.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Record(metric.MeterCounter, "a.b", 1)
+	sink.Record(metric.MeterGauge, "a.c", 3)
+	sink.Record(metric.MeterGauge, "a_b", 4)
+	sink.RecordTagged(metric.MeterCounter, "a_b", []metric.Tag{{Key: "k", Value: "v"}}, 2)
+	// Decreased counters are gauges
+	sink.Record(metric.MeterCounter, "stock", 5)
+	sink.Record(metric.MeterCounter, "stock", -2)
+	sink.Flush()
+
+	expected := `# TYPE a_b counter
+a_b 1
+a_b{k="v"} 2
+# TYPE a_c gauge
+a_c 3
+# TYPE stock gauge
+stock 3
+`
+	if got := scrape(t, sink); got != expected {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, expected)
+	}
+}
@@ -49,3 +49,30 @@ func ExampleNew() {
 	// prefix.histo:123456|ms
 	// prefix.set:member|s
 }
+
+func ExampleTagDialect() {
+	for _, dialect := range []statsd.Dialect{statsd.Plain, statsd.DogStatsD, statsd.InfluxDB} {
+		sink, err := statsd.New(
+			statsd.Output(os.Stdout),
+			statsd.Prefix("prefix"),
+			statsd.TagDialect(dialect))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		client := metric.NewClient(sink)
+		counter := client.RegisterCounter("requests", metric.Tags(
+			metric.Tag{Key: "host", Value: "web1"},
+			metric.Tag{Key: "code", Value: "200"}))
+		counter.Inc(3)
+		client.AdhocGauge("conns", 17, false, metric.Tag{Key: "pool", Value: "a,b"})
+		client.Flush()
+	}
+	// Output:
+	// prefix.conns:17|g
+	// prefix.requests:3|c
+	// prefix.conns:17|g|#pool:a_b
+	// prefix.requests:3|c|#host:web1,code:200
+	// prefix.conns,pool=a_b:17|g
+	// prefix.requests,host=web1,code=200:3|c
+}
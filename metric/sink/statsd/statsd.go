@@ -15,12 +15,24 @@ import (
 // Option is the type of configuration options for the statsd sink factory.
 type Option func(*Sink) error
 
+// Dialect is the statsd protocol dialect used for tags
+type Dialect int
+
+// Statsd dialects. Plain statsd has no tags, so tags are left out.
+const (
+	Plain     Dialect = iota
+	DogStatsD         // name:value|type|#key:value,key2:value2
+	InfluxDB          // name,key=value,key2=value2:value|type (InfluxDB/Telegraf)
+)
+
 type unlockedSink struct {
-	out    io.Writer
-	max    int
-	prefix string
-	buf    []byte
-	strip  bool
+	out     io.Writer
+	max     int
+	prefix  string
+	buf     []byte
+	strip   bool
+	dialect Dialect
+	err     error // first write error since FlushError()
 }
 
 // Sink is a go-routine safe version of a Statsd sink.
@@ -47,6 +59,17 @@ func Prefix(pfx string) Option {
 	})
 }
 
+// TagDialect sets the dialect used to send tags. Default is Plain, leaving out the tags.
+func TagDialect(d Dialect) Option {
+	return Option(func(s *Sink) error {
+		if d < Plain || d > InfluxDB {
+			return fmt.Errorf("Unknown statsd dialect %d", d)
+		}
+		s.dialect = d
+		return nil
+	})
+}
+
 // Peer is the address of the statsd UDP server
 func Peer(addr string) Option {
 	return Option(func(s *Sink) error {
@@ -103,9 +126,19 @@ func (s *Sink) Record(mtype int, name string, value interface{}) {
 }
 
 func (s *unlockedSink) Record(mtype int, name string, value interface{}) {
+	s.RecordTagged(mtype, name, nil, value)
+}
+
+// RecordTagged records a value with tags
+func (s *Sink) RecordTagged(mtype int, name string, tags []metric.Tag, value interface{}) {
+	s.mu.Lock()
+	s.unlockedSink.RecordTagged(mtype, name, tags, value)
+	s.mu.Unlock()
+}
+
+func (s *unlockedSink) RecordTagged(mtype int, name string, tags []metric.Tag, value interface{}) {
 	curbuflen := len(s.buf)
-	s.buf = append(s.buf, s.prefix...)
-	s.buf = append(s.buf, name...)
+	s.appendName(name, tags)
 	s.buf = append(s.buf, ':')
 	switch v := value.(type) {
 	case string:
@@ -118,6 +151,7 @@ func (s *unlockedSink) Record(mtype int, name string, value interface{}) {
 	s.buf = append(s.buf, '|')
 	s.appendType(mtype)
 	// sampe rate not supported
+	s.appendTags(tags)
 	s.buf = append(s.buf, '\n')
 	s.flushIfBufferFull(curbuflen)
 }
@@ -130,14 +164,25 @@ func (s *Sink) RecordNumeric64(mtype int, name string, value num64.Numeric64) {
 }
 
 func (s *unlockedSink) RecordNumeric64(mtype int, name string, value num64.Numeric64) {
+	s.RecordNumeric64Tagged(mtype, name, nil, value)
+}
+
+// RecordNumeric64Tagged records a Numeric64 value with tags
+func (s *Sink) RecordNumeric64Tagged(mtype int, name string, tags []metric.Tag, value num64.Numeric64) {
+	s.mu.Lock()
+	s.unlockedSink.RecordNumeric64Tagged(mtype, name, tags, value)
+	s.mu.Unlock()
+}
+
+func (s *unlockedSink) RecordNumeric64Tagged(mtype int, name string, tags []metric.Tag, value num64.Numeric64) {
 	curbuflen := len(s.buf)
-	s.buf = append(s.buf, s.prefix...)
-	s.buf = append(s.buf, name...)
+	s.appendName(name, tags)
 	s.buf = append(s.buf, ':')
 	s.appendNumeric64(value)
 	s.buf = append(s.buf, '|')
 	s.appendType(mtype)
 	// sample rate not supported
+	s.appendTags(tags)
 	s.buf = append(s.buf, '\n')
 	s.flushIfBufferFull(curbuflen)
 }
@@ -152,6 +197,18 @@ func (s *unlockedSink) Flush() {
 	s.flush(0)
 }
 
+// FlushError returns the first error writing data since the last call - implementing metric.ErrorSink.
+func (s *Sink) FlushError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unlockedSink.FlushError()
+}
+
+func (s *unlockedSink) FlushError() (err error) {
+	err, s.err = s.err, nil
+	return
+}
+
 func (s *unlockedSink) flushIfBufferFull(lastSafeLen int) {
 	if len(s.buf) > s.max {
 		s.flush(lastSafeLen)
@@ -167,10 +224,14 @@ func (s *unlockedSink) flush(n int) {
 	}
 
 	// Trim the last \n, StatsD does not like it.
+	var err error
 	if s.strip {
-		s.out.Write(s.buf[:n-1])
+		_, err = s.out.Write(s.buf[:n-1])
 	} else {
-		s.out.Write(s.buf[:n])
+		_, err = s.out.Write(s.buf[:n])
+	}
+	if err != nil && s.err == nil {
+		s.err = err
 	}
 
 	if n < len(s.buf) {
@@ -179,6 +240,51 @@ func (s *unlockedSink) flush(n int) {
 	s.buf = s.buf[:len(s.buf)-n]
 }
 
+// appendName appends the prefixed name - and the tags for InfluxDB.
+func (s *unlockedSink) appendName(name string, tags []metric.Tag) {
+	s.buf = append(s.buf, s.prefix...)
+	s.buf = append(s.buf, name...)
+	if s.dialect == InfluxDB {
+		for _, t := range tags {
+			s.buf = append(s.buf, ',')
+			s.appendTagPart(t.Key)
+			s.buf = append(s.buf, '=')
+			s.appendTagPart(t.Value)
+		}
+	}
+}
+
+// appendTags appends the tags for DogStatsD
+func (s *unlockedSink) appendTags(tags []metric.Tag) {
+	if s.dialect != DogStatsD || len(tags) == 0 {
+		return
+	}
+	s.buf = append(s.buf, "|#"...)
+	for i, t := range tags {
+		if i > 0 {
+			s.buf = append(s.buf, ',')
+		}
+		s.appendTagPart(t.Key)
+		if t.Value != "" {
+			s.buf = append(s.buf, ':')
+			s.appendTagPart(t.Value)
+		}
+	}
+}
+
+// appendTagPart appends a tag key or value replacing characters
+// with special meaning in the protocol by '_'.
+func (s *unlockedSink) appendTagPart(str string) {
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; c {
+		case ':', '|', ',', '=', '#', '@', ' ', '\n':
+			s.buf = append(s.buf, '_')
+		default:
+			s.buf = append(s.buf, c)
+		}
+	}
+}
+
 func (s *unlockedSink) appendType(t int) {
 	switch t {
 	case metric.MeterGauge:
@@ -0,0 +1,51 @@
+package metric
+
+import (
+	"github.com/One-com/gone/metric/num64"
+)
+
+// Tag is a dimension of a metric, like Tag{Key: "host", Value: "web1"}.
+// Use tags instead of encoding dimensions into dotted metric names.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// TaggedSink is implemented by Sinks which can record readings with tags.
+// This is an extension of the Sink interface, so Sinks without tag support keep working.
+// They get the readings of tagged Meters and ad-hoc events without the tags.
+// If a TaggedSink returns an UnlockedSink(), that should be a TaggedSink too.
+type TaggedSink interface {
+	Sink
+	// RecordTagged is Record with tags. Tags may be nil.
+	RecordTagged(mtype int, name string, tags []Tag, value interface{})
+	// RecordNumeric64Tagged is RecordNumeric64 with tags. Tags may be nil.
+	RecordNumeric64Tagged(mtype int, name string, tags []Tag, value num64.Numeric64)
+}
+
+// Meters which can be given tags when registered with the Tags() MOption
+type taggable interface {
+	setTags([]Tag)
+}
+
+// record a value with tags at the sink if it supports it.
+func record(s Sink, mtype int, name string, tags []Tag, value interface{}) {
+	if len(tags) > 0 {
+		if ts, ok := s.(TaggedSink); ok {
+			ts.RecordTagged(mtype, name, tags, value)
+			return
+		}
+	}
+	s.Record(mtype, name, value)
+}
+
+// recordNumeric64 records a Numeric64 value with tags at the sink if it supports it.
+func recordNumeric64(s Sink, mtype int, name string, tags []Tag, value num64.Numeric64) {
+	if len(tags) > 0 {
+		if ts, ok := s.(TaggedSink); ok {
+			ts.RecordNumeric64Tagged(mtype, name, tags, value)
+			return
+		}
+	}
+	s.RecordNumeric64(mtype, name, value)
+}